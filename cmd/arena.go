@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/ai"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/arena"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/book"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/engine"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/solver"
+)
+
+// runArena plays games between computer players without a window,
+// and prints the record and rating of each.
+func runArena(args []string) {
+	flags := flag.NewFlagSet("arena", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: tictactoe arena [flags] <player> <player> [<player>...]\n\n")
+		fmt.Fprintf(flags.Output(), "Players are computer players, e.g. ai:intermediate, mcts:time=1s or \"engine:movetime=500ms ./bin/tictactoe-engine\", who all play each other.\n\n")
+		flags.PrintDefaults()
+	}
+	variantName := flags.String("variant", game.Standard.Name, "game variant to play")
+	openingName := flags.String("opening", string(game.NoOpening), "opening protocol to play")
+	games := flags.Int("games", 100, "number of games played by every pair of players")
+	workers := flags.Int("workers", 0, "number of games played at once, or zero for one per CPU")
+	seed := flags.Int64("seed", 1, "seed of the random choices made by the players")
+	dbPaths := flags.String("db", defaultDB, "comma-separated list of position databases the players look positions up in")
+	weightsPath := flags.String("weights", defaultWeights, "file of the weights the players evaluate positions with, as written by tictactoe tune")
+	bookPath := flags.String("book", "", "opening book, as written by tictactoe book, the players play from before searching")
+	quiet := flags.Bool("quiet", false, "do not report progress while the games are played")
+	flags.Parse(args)
+
+	if flags.NArg() < 2 || *games < 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	variant, err := game.VariantByName(*variantName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	opening, err := game.OpeningByName(*openingName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	dbs, err := loadDBs(*dbPaths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	weights, err := loadWeights(*weightsPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var openings *book.Book
+	if *bookPath != "" {
+		if openings, err = book.Load(*bookPath); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	oracle := solver.NewOracle(dbs...)
+	entrants := []arena.Entrant{}
+	for _, spec := range flags.Args() {
+		spec := spec
+		entrant := arena.Entrant{
+			Name: spec,
+			New: func(seed int64) (ai.Bot, error) {
+				if command := strings.TrimPrefix(spec, "engine:"); command != spec {
+					e, err := engine.Launch(command)
+					if err != nil {
+						return nil, err
+					}
+					return e, e.SetOption("seed", strconv.FormatInt(seed, 10))
+				}
+				bot, err := ai.ParseBot(spec, seed)
+				if err != nil {
+					return nil, err
+				}
+				if m, ok := bot.(*ai.Minimax); ok {
+					m.Oracle = oracle
+					m.Weights = weights
+				}
+				if openings != nil {
+					bot = &book.Bot{Book: openings, Bot: bot, Rand: rand.New(rand.NewSource(seed))}
+				}
+				return bot, nil
+			},
+		}
+		bot, err := entrant.New(0)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		if closer, ok := bot.(io.Closer); ok {
+			closer.Close()
+		}
+		entrants = append(entrants, entrant)
+	}
+
+	config := arena.Config{
+		Variant: variant,
+		Opening: opening,
+		Games:   *games,
+		Workers: *workers,
+		Seed:    *seed,
+	}
+	if !*quiet {
+		config.Progress = func(played, total int) {
+			fmt.Fprintf(os.Stderr, "\rplayed %d/%d games", played, total)
+			if played == total {
+				fmt.Fprintln(os.Stderr)
+			}
+		}
+	}
+
+	start := time.Now()
+	results, err := arena.Run(context.Background(), config, entrants)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\nerror: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%d games of %s in %s\n\n", len(results), variant.Name, time.Since(start).Round(time.Millisecond))
+	printStandings(entrants, arena.Standings(results, len(entrants)))
+	if len(entrants) > 2 {
+		fmt.Println()
+		printHeadToHead(entrants, results)
+	}
+}
+
+func printStandings(entrants []arena.Entrant, standings []arena.Standing) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RANK\tPLAYER\tGAMES\tWINS\tDRAWS\tLOSSES\tSCORE\tELO\t95% CI")
+	for i, s := range standings {
+		fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%d\t%d\t%.1f%%\t%+.0f\t%s\n", i+1, entrants[s.Entrant].Name, s.Games(), s.Wins, s.Draws, s.Losses, s.Score()*100, s.Elo, interval(s))
+	}
+	w.Flush()
+}
+
+// interval returns the 95% confidence interval of the rating of s.
+func interval(s arena.Standing) string {
+	if math.IsInf(s.Margin, 1) {
+		return "-"
+	}
+	return fmt.Sprintf("[%+.0f, %+.0f]", s.Elo-s.Margin, s.Elo+s.Margin)
+}
+
+// printHeadToHead prints the record of every player against every
+// other, as wins, draws and losses.
+func printHeadToHead(entrants []arena.Entrant, results []arena.Game) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprint(w, "PLAYER")
+	for _, e := range entrants {
+		fmt.Fprintf(w, "\t%s", e.Name)
+	}
+	fmt.Fprintln(w)
+	for i, e := range entrants {
+		fmt.Fprint(w, e.Name)
+		for j := range entrants {
+			if i == j {
+				fmt.Fprint(w, "\t-")
+				continue
+			}
+			r := arena.HeadToHead(results, i, j)
+			fmt.Fprintf(w, "\t+%d =%d -%d", r.Wins, r.Draws, r.Losses)
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+}
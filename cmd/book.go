@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/book"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/record"
+)
+
+// runBook builds an opening book from the moves played in saved games.
+func runBook(args []string) {
+	flags := flag.NewFlagSet("book", flag.ExitOnError)
+	saveDir := flags.String("save-dir", "", "directory of the saved games to build the book from")
+	plies := flags.Int("plies", 12, "number of moves of each game added to the book")
+	output := flags.String("o", "", "file to write the book to")
+	flags.Parse(args)
+
+	if *saveDir == "" || *output == "" || *plies < 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	games, err := record.LoadDir(*saveDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	b := book.New()
+	for _, g := range games {
+		if err := b.Add(g, *plies); err != nil {
+			fmt.Fprintf(os.Stderr, "error: game played %s: %v\n", g.Played.Format("2006-01-02 15:04:05"), err)
+			os.Exit(1)
+		}
+	}
+	if err := b.Save(*output); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("wrote %d positions from %d games to %s\n", b.Len(), len(games), *output)
+}
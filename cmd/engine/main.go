@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/ai"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/engine"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/solver"
+)
+
+// tictactoe-engine is the reference engine: it plays with the
+// built-in computer players over the engine protocol, on its
+// standard input and output.
+func main() {
+	spec := flag.String("bot", "ai", "computer player to play with, as ai[:<difficulty>] or mcts[:<settings>]")
+	dbPaths := flag.String("db", "", "comma-separated list of position databases to look positions up in")
+	weightsPath := flag.String("weights", "", "file of the weights to evaluate positions with, as written by tictactoe tune")
+	flag.Parse()
+
+	oracle, err := loadOracle(*dbPaths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	var weights *ai.Weights
+	if *weightsPath != "" {
+		weights, err = ai.LoadWeights(*weightsPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	newBot := func(seed int64) (ai.Bot, error) {
+		bot, err := ai.ParseBot(*spec, seed)
+		if m, ok := bot.(*ai.Minimax); ok {
+			m.Oracle = oracle
+			m.Weights = weights
+		}
+		return bot, err
+	}
+	if _, err := newBot(0); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := engine.Serve(os.Stdin, os.Stdout, "go-tictactoe "+*spec, newBot); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func loadOracle(paths string) (*solver.Oracle, error) {
+	dbs := []*solver.DB{}
+	if paths != "" {
+		for _, path := range strings.Split(paths, ",") {
+			d, err := solver.LoadDB(path)
+			if err != nil {
+				return nil, err
+			}
+			dbs = append(dbs, d)
+		}
+	}
+	return solver.NewOracle(dbs...), nil
+}
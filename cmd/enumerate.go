@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/enumerate"
+)
+
+// runEnumerate counts every position reachable on a board, checking
+// the rules of the game find the same wins and ties as its lines.
+func runEnumerate(args []string) {
+	flags := flag.NewFlagSet("enumerate", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: tictactoe enumerate [flags]\n\n")
+		flags.PrintDefaults()
+	}
+	size := flags.Int("size", 3, "number of cells along each side of the board")
+	length := flags.Int("length", 0, "number of shapes in a row needed to win, or zero for a whole row")
+	workers := flags.Int("workers", 0, "number of goroutines walking positions at once, or zero for one per CPU")
+	flags.Parse(args)
+
+	if flags.NArg() != 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if *length == 0 {
+		*length = *size
+	}
+
+	stats, err := enumerate.Run(context.Background(), enumerate.Config{Size: *size, Length: *length, Workers: *workers})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%5s  %10s  %10s\n", "moves", "positions", "unique")
+	for depth := range stats.Positions {
+		fmt.Printf("%5d  %10d  %10d\n", depth, stats.Positions[depth], stats.Unique[depth])
+	}
+	fmt.Printf("%5s  %10d  %10d\n\n", "total", enumerate.Total(stats.Positions), enumerate.Total(stats.Unique))
+
+	fmt.Printf("%-7s  %10s  %10s\n", "over", "positions", "unique")
+	for o := enumerate.CrossWin; o <= enumerate.Draw; o++ {
+		fmt.Printf("%-7s  %10d  %10d\n", o, stats.Terminal[o], stats.UniqueTerminal[o])
+	}
+	fmt.Println()
+
+	if stats.Mismatches > 0 {
+		for _, m := range stats.Examples {
+			fmt.Fprintf(os.Stderr, "mismatch: %s\n", m)
+		}
+		fmt.Fprintf(os.Stderr, "error: the rules disagree with the lines of %d positions\n", stats.Mismatches)
+		os.Exit(1)
+	}
+	fmt.Println("the rules agree with the lines of every position")
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/solver"
+)
+
+// gendb solves every position reachable on a board and writes them
+// to a position database.
+func gendb(args []string) {
+	flags := flag.NewFlagSet("gendb", flag.ExitOnError)
+	size := flags.Int("size", 3, "number of cells along each side of the board")
+	length := flags.Int("length", 0, "number of shapes in a row needed to win, or zero for a whole row")
+	output := flags.String("o", "", "file to write the database to")
+	flags.Parse(args)
+
+	if *output == "" || *size < 1 || *length < 0 || *length > *size {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if *length == 0 {
+		*length = *size
+	}
+
+	s := solver.New(*size, *length)
+	d := s.Generate()
+	if err := d.Save(*output); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("wrote %d positions to %s\n", d.Len(), *output)
+}
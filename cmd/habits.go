@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/habits"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/record"
+)
+
+// runHabits learns the habits of a profile from the games saved while
+// playing under it, replacing those learned before.
+func runHabits(args []string) {
+	flags := flag.NewFlagSet("habits", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: tictactoe habits [flags] <profile>\n\n")
+		fmt.Fprintf(flags.Output(), "Games are only learned from if they were saved while playing with -profile <profile>.\n\n")
+		flags.PrintDefaults()
+	}
+	saveDir := flags.String("save-dir", "", "directory of the saved games to learn from")
+	profileDir := flags.String("profile-dir", defaultProfileDir(), "directory the habits of every profile are kept in")
+	flags.Parse(args)
+
+	if *saveDir == "" || flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	profile := flags.Arg(0)
+	path, err := habits.Path(*profileDir, profile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	games, err := record.LoadDir(*saveDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	model := habits.NewModel()
+	learned := 0
+	for _, g := range games {
+		played := false
+		for _, p := range g.Profiles {
+			played = played || p == profile
+		}
+		if !played {
+			continue
+		}
+		if err := model.LearnGame(g, profile); err != nil {
+			fmt.Fprintf(os.Stderr, "error: game played %s: %v\n", g.Played.Format("2006-01-02 15:04:05"), err)
+			os.Exit(1)
+		}
+		learned++
+	}
+
+	if err := model.Save(path); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("learned replies to %d positions from %d games, written to %s\n", model.Len(), learned, path)
+}
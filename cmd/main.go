@@ -1,11 +1,218 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
 	"github.com/faiface/pixel/pixelgl"
 
 	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/ai"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/book"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/solver"
+)
+
+const (
+	// defaultDB is the position database shipped with the game. It
+	// is skipped if missing, unlike databases given with -db.
+	defaultDB = "data/3x3.db"
+
+	// defaultWeights is the file of weights written by the tune
+	// command that the computer evaluates positions with. It is
+	// skipped if missing, unlike weights given with -weights.
+	defaultWeights = "data/weights.json"
 )
 
 func main() {
-	pixelgl.Run(tictactoe.NewGame)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "solve":
+			solve(os.Args[2:])
+			return
+		case "gendb":
+			gendb(os.Args[2:])
+			return
+		case "arena":
+			runArena(os.Args[2:])
+			return
+		case "train":
+			train(os.Args[2:])
+			return
+		case "tune":
+			runTune(os.Args[2:])
+			return
+		case "habits":
+			runHabits(os.Args[2:])
+			return
+		case "book":
+			runBook(os.Args[2:])
+			return
+		case "tree":
+			runTree(os.Args[2:])
+			return
+		case "enumerate":
+			runEnumerate(os.Args[2:])
+			return
+		}
+	}
+
+	names := []string{}
+	for _, v := range game.Variants {
+		names = append(names, v.Name)
+	}
+	difficulties := []string{}
+	for _, d := range ai.Difficulties {
+		difficulties = append(difficulties, d.Name)
+	}
+	openings := []string{}
+	for _, o := range game.Openings {
+		openings = append(openings, string(o))
+	}
+
+	variantName := flag.String("variant", game.Standard.Name, fmt.Sprintf("game variant to play (%s)", strings.Join(names, ", ")))
+	openingName := flag.String("opening", string(game.NoOpening), fmt.Sprintf("opening protocol to play (%s)", strings.Join(openings, ", ")))
+	matchFormat := flag.String("match", "single", "match format to play (single, bo<N> for best of N games, or first-to-<N>)")
+	handicapSettings := flag.String("handicap", "", "handicap given to the weaker player, e.g. weak=2,stones=1,center,strong-length=4,weak-length=3")
+	teams := flag.Bool("teams", false, "play two teams of two, with team members taking turns to move")
+	player1 := flag.String("player1", "mouse", "what controls player 1: mouse, keyboard, ai[:<difficulty>], mcts[:<settings>], engine:<command>, learned:<file>, adaptive[:<difficulty>], remote:<addr> or listen:<addr>")
+	player2 := flag.String("player2", "mouse", "what controls player 2: mouse, keyboard, ai[:<difficulty>], mcts[:<settings>], engine:<command>, learned:<file>, adaptive[:<difficulty>], remote:<addr> or listen:<addr>")
+	computerPlayer := flag.Int("computer", 0, "number of a player controlled by the computer, shorthand for -player<N> ai:<difficulty>")
+	difficulty := flag.String("difficulty", ai.Expert.Name, fmt.Sprintf("difficulty of the computer player given with -computer (%s)", strings.Join(difficulties, ", ")))
+	seed := flag.Int64("seed", 0, "seed of the mistakes made by computer players, or zero for a random seed")
+	saveDir := flag.String("save-dir", "", "directory to save finished games to")
+	dbPaths := flag.String("db", defaultDB, "comma-separated list of position databases the computer looks positions up in")
+	weightsPath := flag.String("weights", defaultWeights, "file of the weights the computer evaluates positions with, as written by tictactoe tune")
+	bookPath := flag.String("book", "", "opening book, as written by tictactoe book, computer players play from before searching")
+	profile := flag.String("profile", "", "name of the profile the habits of the human players are learned under by adaptive computer players")
+	profileDir := flag.String("profile-dir", defaultProfileDir(), "directory the habits of every profile are kept in")
+	comment := flag.Bool("commentary", false, "comment on every move beneath the board, and in saved games")
+	flag.Parse()
+
+	variant, err := game.VariantByName(*variantName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	opening, err := game.OpeningByName(*openingName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	format, err := game.ParseMatchFormat(*matchFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	players := [2]string{*player1, *player2}
+	switch *computerPlayer {
+	case 0:
+	case 1, 2:
+		players[*computerPlayer-1] = "ai:" + *difficulty
+	default:
+		fmt.Fprintf(os.Stderr, "error: invalid computer player %d: expected 0, 1 or 2\n", *computerPlayer)
+		os.Exit(1)
+	}
+
+	dbs, err := loadDBs(*dbPaths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	weights, err := loadWeights(*weightsPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var openingBook *book.Book
+	if *bookPath != "" {
+		if openingBook, err = book.Load(*bookPath); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+
+	handicap, err := game.ParseHandicap(*handicapSettings)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	pixelgl.Run(func() {
+		tictactoe.NewGame(tictactoe.Options{
+			Variant:  variant,
+			Opening:  opening,
+			Match:    format,
+			Handicap: handicap,
+			Teams:    *teams,
+			Players:  players,
+			Seed:     *seed,
+			SaveDir:  *saveDir,
+			DBs:      dbs,
+			Weights:  weights,
+			Book:     openingBook,
+
+			Profile:    *profile,
+			ProfileDir: *profileDir,
+			Commentary: *comment,
+		})
+	})
+}
+
+// loadDBs loads the position databases in a comma-separated list
+// of paths.
+func loadDBs(paths string) ([]*solver.DB, error) {
+	dbs := []*solver.DB{}
+	if paths == "" {
+		return dbs, nil
+	}
+
+	for _, path := range strings.Split(paths, ",") {
+		d, err := solver.LoadDB(path)
+		if errors.Is(err, fs.ErrNotExist) && path == defaultDB {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		dbs = append(dbs, d)
+	}
+	return dbs, nil
+}
+
+// loadWeights loads the weights in the file at path, or returns nil
+// if path is empty or is the missing default file.
+func loadWeights(path string) (*ai.Weights, error) {
+	if path == "" {
+		return nil, nil
+	}
+	w, err := ai.LoadWeights(path)
+	if errors.Is(err, fs.ErrNotExist) && path == defaultWeights {
+		return nil, nil
+	}
+	return w, err
+}
+
+// defaultProfileDir returns the directory profiles are kept in by
+// default, in the configuration directory of the user.
+func defaultProfileDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "profiles"
+	}
+	return filepath.Join(dir, "go-tictactoe", "profiles")
 }
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/solver"
+)
+
+// solve prints the value of a position and its best moves.
+func solve(args []string) {
+	flags := flag.NewFlagSet("solve", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: tictactoe solve [-length N] <position>\n\n")
+		fmt.Fprintf(flags.Output(), "Positions are written row by row, with rows separated by slashes, e.g. x.o/.x./...\n\n")
+		flags.PrintDefaults()
+	}
+	length := flags.Int("length", 0, "number of shapes in a row needed to win, or zero for a whole row")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	p, err := solver.ParsePosition(flags.Arg(0), *length)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	s := solver.New(p.Size, p.Length)
+	value, best, err := s.Best(p)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	moves := []string{}
+	for _, i := range best {
+		m := p.Move(i)
+		moves = append(moves, fmt.Sprintf("%d,%d", m.X, m.Y))
+	}
+	fmt.Printf("%s to move: %s\n", p.Turn, value)
+	if len(moves) > 0 {
+		fmt.Printf("best moves: %s\n", strings.Join(moves, " "))
+	}
+	fmt.Printf("positions searched: %d\n", s.Nodes())
+}
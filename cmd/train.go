@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/learn"
+)
+
+// evaluationGames is the number of games played against random moves
+// every time training progress is reported.
+const evaluationGames = 1000
+
+// train trains a model by self-play and writes it to a file the
+// computer player can load.
+func train(args []string) {
+	flags := flag.NewFlagSet("train", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: tictactoe train [flags] -o <file>\n\n")
+		fmt.Fprintf(flags.Output(), "The model written can be played against with the player learned:<file>.\n\n")
+		flags.PrintDefaults()
+	}
+	size := flags.Int("size", 3, "number of cells along each side of the board")
+	length := flags.Int("length", 0, "number of shapes in a row needed to win, or zero for a whole row")
+	kind := flags.String("model", "", fmt.Sprintf("model to train (table or network), or empty for a table on boards up to %dx%d and a network on larger ones", learn.MaxTableSize, learn.MaxTableSize))
+	games := flags.Int("games", 50000, "number of games played against itself")
+	seed := flags.Int64("seed", 1, "seed of the random moves and initial weights, so training can be repeated")
+	rate := flags.Float64("rate", 0, "learning rate, or zero for 0.2 for tables and 0.01 for networks")
+	lambda := flags.Float64("lambda", 0.7, "weight of the outcome of a game against the value of the next position, from 0 to 1")
+	epsilon := flags.Float64("epsilon", 0.1, "probability of playing a random move instead of the best one")
+	hidden := flags.Int("hidden", 64, "number of hidden units of a network")
+	report := flags.Int("report", 0, "number of games between progress reports, or zero for ten reports")
+	output := flags.String("o", "", "file to write the model to")
+	flags.Parse(args)
+
+	if *output == "" || *size < 1 || *size > 255 || *length < 0 || *length > *size || *games < 1 || *hidden < 1 || *report < 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if *length == 0 {
+		*length = *size
+	}
+	if *kind == "" {
+		*kind = "table"
+		if *size > learn.MaxTableSize {
+			*kind = "network"
+		}
+	}
+	if *report == 0 {
+		*report = (*games + 9) / 10
+	}
+
+	r := rand.New(rand.NewSource(*seed))
+	var model learn.Model
+	switch *kind {
+	case "table":
+		if *size > learn.MaxTableSize {
+			fmt.Fprintf(os.Stderr, "error: tables are only kept for boards up to %dx%d\n", learn.MaxTableSize, learn.MaxTableSize)
+			os.Exit(1)
+		}
+		model = learn.NewTable(*size, *length)
+		if *rate == 0 {
+			*rate = 0.2
+		}
+	case "network":
+		model = learn.NewNetwork(*size, *length, *hidden, r)
+		if *rate == 0 {
+			*rate = 0.01
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "error: unknown model %q\n", *kind)
+		os.Exit(1)
+	}
+
+	trainer := &learn.Trainer{Model: model, Rate: *rate, Lambda: *lambda, Epsilon: *epsilon, Rand: r}
+	for n := 1; n <= *games; n++ {
+		trainer.Play()
+		if n%*report == 0 || n == *games {
+			wins, draws, losses := learn.Evaluate(model, evaluationGames, rand.New(rand.NewSource(*seed)))
+			fmt.Printf("%d games: %d wins, %d draws and %d losses in %d games against random moves\n", n, wins, draws, losses, evaluationGames)
+		}
+	}
+
+	if err := learn.Save(*output, model); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if t, ok := model.(*learn.Table); ok {
+		fmt.Printf("wrote %d positions to %s\n", t.Len(), *output)
+		return
+	}
+	fmt.Printf("wrote a network of %d hidden units to %s\n", model.(*learn.Network).Hidden(), *output)
+}
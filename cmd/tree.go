@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/solver"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/tree"
+)
+
+// runTree writes the game tree from a position in the DOT format of
+// Graphviz.
+func runTree(args []string) {
+	flags := flag.NewFlagSet("tree", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: tictactoe tree [flags] [position]\n\n")
+		fmt.Fprintf(flags.Output(), "Positions are written row by row, with rows separated by slashes, e.g. x.o/.x./...\n")
+		fmt.Fprintf(flags.Output(), "The tree starts from an empty board if no position is given.\n\n")
+		flags.PrintDefaults()
+	}
+	size := flags.Int("size", 3, "size of the empty board the tree starts from if no position is given")
+	length := flags.Int("length", 0, "number of shapes in a row needed to win, or zero for a whole row")
+	depth := flags.Int("depth", 0, "number of moves from the position the tree goes down to, or zero for the whole tree")
+	symmetry := flags.Bool("symmetry", false, "leave out moves leading to the same position up to a rotation or reflection of the board")
+	transpositions := flags.Bool("transpositions", false, "merge positions reached by different orders of moves, making a graph of the tree")
+	dbPaths := flags.String("db", defaultDB, "comma-separated list of position databases positions are looked up in")
+	output := flags.String("o", "", "file to write the tree to, or empty for the standard output")
+	flags.Parse(args)
+
+	if flags.NArg() > 1 || *size < 1 || *depth < 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	position := strings.TrimSuffix(strings.Repeat(strings.Repeat(".", *size)+"/", *size), "/")
+	if flags.NArg() == 1 {
+		position = flags.Arg(0)
+	}
+	p, err := solver.ParsePosition(position, *length)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	dbs, err := loadDBs(*dbPaths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	config := tree.Config{Depth: *depth, Symmetry: *symmetry, Transpositions: *transpositions}
+	if err := tree.Write(out, p, solver.NewOracle(dbs...), config); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/ai"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/arena"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/tune"
+)
+
+// runTune evolves the weights the computer evaluates positions with,
+// and writes the best ones found to a file.
+func runTune(args []string) {
+	flags := flag.NewFlagSet("tune", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: tictactoe tune [flags] -o <file>\n\n")
+		fmt.Fprintf(flags.Output(), "Writing the weights to %s makes the computer play with them.\n\n", defaultWeights)
+		flags.PrintDefaults()
+	}
+	variantName := flags.String("variant", game.Gomoku.Name, "game variant to tune the weights for")
+	population := flags.Int("population", 12, "number of weights playing each other in every generation")
+	generations := flags.Int("generations", 10, "number of generations bred")
+	mutation := flags.Float64("mutation", 0.2, "probability of each weight of a child being mutated")
+	games := flags.Int("games", 2, "number of games played by every pair of weights in a generation")
+	depth := flags.Int("depth", 2, "number of moves ahead searched by the players")
+	blunder := flags.Float64("blunder", 0.05, "probability of the players playing a random move, so their games differ")
+	workers := flags.Int("workers", 0, "number of games played at once, or zero for one per CPU")
+	seed := flags.Int64("seed", 1, "seed of the breeding and of the games played")
+	start := flags.String("weights", defaultWeights, "file of the weights the first generation is bred from")
+	output := flags.String("o", "", "file to write the best weights to")
+	flags.Parse(args)
+
+	if *output == "" || *population < 2 || *generations < 1 || *games < 1 || *depth < 1 || *mutation < 0 || *mutation > 1 || *blunder < 0 || *blunder > 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	variant, err := game.VariantByName(*variantName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	weights, err := loadWeights(*start)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if weights == nil {
+		weights = &ai.DefaultWeights
+	}
+
+	best, err := tune.Evolve(context.Background(), tune.Config{
+		Variant:     variant,
+		Difficulty:  ai.Difficulty{Name: "tune", Depth: *depth, Blunder: *blunder},
+		Population:  *population,
+		Generations: *generations,
+		Mutation:    *mutation,
+		Games:       *games,
+		Workers:     *workers,
+		Seed:        *seed,
+		Start:       *weights,
+		Generation: func(n int, population []ai.Weights, standings []arena.Standing) {
+			s := standings[0]
+			fmt.Printf("generation %d: best scored %.1f%% (%+.0f Elo): %s\n", n, 100*s.Score(), s.Elo, formatWeights(population[s.Entrant]))
+		},
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := ai.SaveWeights(*output, &best); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("wrote the best weights to %s\n", *output)
+}
+
+// formatWeights lists every weight as <pattern>=<weight>.
+func formatWeights(w ai.Weights) string {
+	settings := []string{}
+	for p, weight := range w {
+		settings = append(settings, fmt.Sprintf("%s=%d", ai.Pattern(p), weight))
+	}
+	return strings.Join(settings, ",")
+}
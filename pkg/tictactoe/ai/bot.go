@@ -0,0 +1,66 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/learn"
+)
+
+// Bot chooses the moves of a computer player.
+type Bot interface {
+	// Move returns the move to make in s, leaving s unchanged. It
+	// returns early with an error once ctx is canceled, while a
+	// deadline on ctx bounds how long it thinks for, and the best
+	// move found by then is returned.
+	Move(ctx context.Context, s *game.State) (game.Move, error)
+}
+
+// Ponderer is a bot that can keep thinking while the other side is
+// to move.
+type Ponderer interface {
+	// Ponder thinks about s, in which the other side is to move,
+	// until ctx is done, leaving s unchanged.
+	Ponder(ctx context.Context, s *game.State)
+}
+
+// ParseBot parses the description of a bot, which is either "ai" or
+// "ai:<difficulty>" as accepted by ParseDifficulty, or "mcts" or
+// "mcts:<settings>" as accepted by ParseMCTS, or "learned:<path>" to
+// play with the model trained by self-play in the file at path.
+// Random choices are drawn from seed, unless the settings give a seed
+// of their own.
+func ParseBot(spec string, seed int64) (Bot, error) {
+	kind, arg, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "ai":
+		difficulty := Expert
+		if arg != "" {
+			d, err := ParseDifficulty(arg)
+			if err != nil {
+				return nil, err
+			}
+			difficulty = d
+		}
+		return NewMinimax(difficulty, seed), nil
+	case "mcts":
+		bot, err := ParseMCTS(arg)
+		if err != nil {
+			return nil, err
+		}
+		if bot.Seed == 0 {
+			bot.Seed = seed
+		}
+		return bot, nil
+	case "learned":
+		model, err := learn.Load(arg)
+		if err != nil {
+			return nil, err
+		}
+		return &learn.Agent{Model: model}, nil
+	}
+
+	return nil, fmt.Errorf("unknown bot %q", spec)
+}
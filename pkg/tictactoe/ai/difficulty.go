@@ -0,0 +1,114 @@
+package ai
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Difficulty describes how well the computer plays.
+type Difficulty struct {
+	Name string
+
+	// Depth limits how many moves ahead are searched, or zero to
+	// search as deep as the board allows.
+	Depth int
+	// Time, if set, is how long the search is deepened for, one
+	// move at a time up to Depth.
+	Time time.Duration
+	// Blunder is the probability of playing a random move instead
+	// of the best one.
+	Blunder float64
+	// MissForks is the probability of searching only deep enough to
+	// see immediate wins and blocks, overlooking any fork set up by
+	// either side.
+	MissForks float64
+}
+
+var (
+	Beginner = Difficulty{
+		Name:      "beginner",
+		Depth:     2,
+		Blunder:   0.25,
+		MissForks: 1,
+	}
+	Intermediate = Difficulty{
+		Name:      "intermediate",
+		Depth:     4,
+		Blunder:   0.05,
+		MissForks: 0.5,
+	}
+	Expert = Difficulty{
+		Name: "expert",
+	}
+
+	Difficulties = []Difficulty{Beginner, Intermediate, Expert}
+)
+
+// ParseDifficulty parses the name of a difficulty followed by an
+// optional comma-separated list of settings overriding it, e.g.
+// "intermediate,blunder=0.2,forks=0,depth=3,time=2s".
+func ParseDifficulty(difficulty string) (Difficulty, error) {
+	settings := strings.Split(difficulty, ",")
+
+	d, err := difficultyByName(settings[0])
+	if err != nil {
+		return Difficulty{}, err
+	}
+
+	for _, setting := range settings[1:] {
+		key, value, _ := strings.Cut(setting, "=")
+		switch key {
+		case "depth":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return Difficulty{}, fmt.Errorf("invalid difficulty setting %q: expected a positive number", setting)
+			}
+			d.Depth = n
+		case "time":
+			t, err := time.ParseDuration(value)
+			if err != nil || t < 0 {
+				return Difficulty{}, fmt.Errorf("invalid difficulty setting %q: expected a duration such as 2s", setting)
+			}
+			d.Time = t
+		case "blunder", "forks":
+			p, err := strconv.ParseFloat(value, 64)
+			if err != nil || p < 0 || p > 1 {
+				return Difficulty{}, fmt.Errorf("invalid difficulty setting %q: expected a probability between 0 and 1", setting)
+			}
+			if key == "blunder" {
+				d.Blunder = p
+			} else {
+				d.MissForks = p
+			}
+		default:
+			return Difficulty{}, fmt.Errorf("unknown difficulty setting %q", setting)
+		}
+	}
+	return d, nil
+}
+
+func difficultyByName(name string) (Difficulty, error) {
+	for _, d := range Difficulties {
+		if d.Name == name {
+			return d, nil
+		}
+	}
+
+	return Difficulty{}, fmt.Errorf("unknown difficulty %q", name)
+}
+
+// NewMinimax returns a computer player of the given difficulty. Its
+// mistakes are drawn from a random source seeded with seed, so the
+// same difficulty and seed always make the same moves.
+func NewMinimax(d Difficulty, seed int64) *Minimax {
+	return &Minimax{
+		Depth:     d.Depth,
+		Time:      d.Time,
+		Blunder:   d.Blunder,
+		MissForks: d.MissForks,
+		Rand:      rand.New(rand.NewSource(seed)),
+	}
+}
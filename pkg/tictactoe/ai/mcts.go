@@ -0,0 +1,474 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+)
+
+const (
+	// defaultIterations is the number of playouts run by a search
+	// given neither an iteration nor a time budget.
+	defaultIterations = 20000
+
+	// reportedMoves is the number of candidates written to the
+	// report of a search.
+	reportedMoves = 5
+
+	// samples is the number of random cells LocalPolicy tries before
+	// falling back to listing every move it may play.
+	samples = 32
+
+	// ponderIterations is the most playouts run while pondering,
+	// which bounds the memory held by the trees grown.
+	ponderIterations = 10 * defaultIterations
+)
+
+// Policy picks the moves played out from a position until the end
+// of a game.
+type Policy func(s *game.State, r *rand.Rand) game.Move
+
+var (
+	// RandomPolicy plays any legal move.
+	RandomPolicy Policy = func(s *game.State, r *rand.Rand) game.Move {
+		moves := s.Legal()
+		return moves[r.Intn(len(moves))]
+	}
+	// LocalPolicy plays moves near shapes already placed, which
+	// keeps playouts on large boards closer to real games.
+	LocalPolicy Policy = func(s *game.State, r *rand.Rand) game.Move {
+		if s.Phase() != game.PhaseChoice && len(s.History()) > 0 {
+			size := s.Grid.Size()
+			for i := 0; i < samples; i++ {
+				move := game.Move{X: r.Intn(size), Y: r.Intn(size)}
+				if s.Validate(s.Grid.At(move.X, move.Y)) == nil && (size <= 4 || near(s, move, neighborhood)) {
+					return move
+				}
+			}
+		}
+		moves := local(s)
+		return moves[r.Intn(len(moves))]
+	}
+
+	Policies = map[string]Policy{
+		"random": RandomPolicy,
+		"local":  LocalPolicy,
+	}
+)
+
+// MCTS chooses moves with Monte Carlo tree search, using UCT to
+// select which moves to explore. Each worker grows its own tree
+// from the current position, and their results are combined once
+// the budget runs out. The trees are kept after every search, and
+// whatever part of them follows the moves made since is reused by
+// the next one.
+type MCTS struct {
+	// Iterations and Duration bound how many playouts are run and
+	// for how long. A search stops as soon as either is reached, and
+	// runs defaultIterations playouts if neither is set.
+	Iterations int
+	Duration   time.Duration
+
+	// Workers is the number of goroutines running playouts, or zero
+	// to use one per CPU.
+	Workers int
+	// Exploration weighs exploring rarely visited moves against
+	// playing the ones that win the most, or zero for √2.
+	Exploration float64
+	// Policy picks the moves of every playout, or nil for
+	// LocalPolicy.
+	Policy Policy
+	// Seed seeds the playouts of the first worker. Every other
+	// worker uses the next seed along.
+	Seed int64
+
+	// Report, if set, is written the statistics of the most
+	// visited candidates after every search.
+	Report io.Writer
+
+	// mu guards forest, the trees grown by the last search.
+	mu     sync.Mutex
+	forest *forest
+}
+
+// forest holds the tree grown by each worker from the position
+// reached by history.
+type forest struct {
+	history []game.Entry
+	roots   []*node
+}
+
+// Candidate holds the statistics gathered by a search for one of
+// the moves available in a position.
+type Candidate struct {
+	Move   game.Move
+	Visits int
+	// Wins counts playouts won by the side making the move, with
+	// ties counting as half a win.
+	Wins float64
+}
+
+// WinRate returns the fraction of playouts through the move that
+// were won by the side making it.
+func (c Candidate) WinRate() float64 {
+	if c.Visits == 0 {
+		return 0
+	}
+	return c.Wins / float64(c.Visits)
+}
+
+// node is a position in the search tree, reached by playing move.
+type node struct {
+	move   game.Move
+	parent *node
+	// side is the index of the side that made move.
+	side int
+
+	children []*node
+	untried  []game.Move
+	visits   int
+	wins     float64
+}
+
+// Move returns the most visited move for the current side of s,
+// unless a threat search finds a move that must be made. The search
+// stops at the deadline of ctx, if it has one.
+func (m *MCTS) Move(ctx context.Context, s *game.State) (game.Move, error) {
+	if move, ok := forced(s); ok {
+		if m.Report != nil {
+			fmt.Fprintf(m.Report, "%s: forced move %s\n", s.Current().Name, describeMove(move))
+		}
+		return move, nil
+	}
+
+	candidates := m.Search(ctx, s)
+	if err := ctx.Err(); errors.Is(err, context.Canceled) {
+		return game.Move{}, err
+	}
+	if m.Report != nil {
+		m.report(s, candidates)
+	}
+	return candidates[0].Move, nil
+}
+
+// Ponder grows the trees of the search from s, in which the other
+// side is to move, until ctx is done or ponderIterations playouts
+// have been run. Whichever move the other side makes, the next
+// search starts from the part of the trees following it.
+func (m *MCTS) Ponder(ctx context.Context, s *game.State) {
+	if s.Phase() == game.PhaseOver {
+		return
+	}
+	m.run(ctx, s, ponderIterations, time.Time{})
+}
+
+// Search runs playouts from s within the budget of the search and
+// returns every candidate move, most visited first. The state is
+// left unchanged. The search stops early once ctx is done.
+func (m *MCTS) Search(ctx context.Context, s *game.State) []Candidate {
+	iterations := m.Iterations
+	if iterations <= 0 && m.Duration <= 0 {
+		iterations = defaultIterations
+	}
+	deadline := time.Time{}
+	if m.Duration > 0 {
+		deadline = time.Now().Add(m.Duration)
+	}
+	roots := m.run(ctx, s, iterations, deadline)
+
+	stats := map[game.Move]*Candidate{}
+	order := []game.Move{}
+	for _, root := range roots {
+		for _, child := range root.children {
+			c, ok := stats[child.move]
+			if !ok {
+				c = &Candidate{Move: child.move}
+				stats[child.move] = c
+				order = append(order, child.move)
+			}
+			c.Visits += child.visits
+			c.Wins += child.wins
+		}
+	}
+
+	candidates := []Candidate{}
+	for _, move := range order {
+		candidates = append(candidates, *stats[move])
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Visits > candidates[j].Visits
+	})
+	if len(candidates) == 0 {
+		candidates = append(candidates, Candidate{Move: s.Legal()[0]})
+	}
+	return candidates
+}
+
+// run shares out iterations playouts, if non-zero, between the
+// workers, which grow their trees from s until either the playouts
+// or the deadline, if set, run out, or ctx is done. The trees grown
+// are kept for the next search, and returned.
+func (m *MCTS) run(ctx context.Context, s *game.State, iterations int, deadline time.Time) []*node {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	workers := m.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if iterations > 0 && workers > iterations {
+		workers = iterations
+	}
+
+	roots := m.reuse(s, workers)
+	grown := make(chan struct{}, workers)
+	for i := 0; i < workers; i++ {
+		budget := 0
+		if iterations > 0 {
+			budget = iterations / workers
+			if i < iterations%workers {
+				budget++
+			}
+		}
+		go func(root *node, seed int64, budget int) {
+			m.grow(ctx, s.Clone(), root, rand.New(rand.NewSource(seed)), budget, deadline)
+			grown <- struct{}{}
+		}(roots[i], m.Seed+int64(i), budget)
+	}
+	for i := 0; i < workers; i++ {
+		<-grown
+	}
+
+	m.forest = &forest{history: append([]game.Entry{}, s.History()...), roots: roots}
+	return roots
+}
+
+// reuse returns a root for each worker to grow its tree from s on.
+// The trees of the last search are descended along the moves made
+// since, if it was made from a position s was reached from by the
+// same number of workers, and new trees are planted otherwise.
+func (m *MCTS) reuse(s *game.State, workers int) []*node {
+	roots := []*node{}
+	if f := m.forest; f != nil && len(f.roots) == workers && len(f.history) <= len(s.History()) && sameHistory(f.history, s.History()[:len(f.history)]) {
+		for _, root := range f.roots {
+			n := root
+			for _, entry := range s.History()[len(f.history):] {
+				if n = n.child(entry.Move); n == nil {
+					break
+				}
+			}
+			if n == nil {
+				break
+			}
+			n.parent = nil
+			roots = append(roots, n)
+		}
+	}
+	m.forest = nil
+	if len(roots) == workers {
+		return roots
+	}
+
+	roots = roots[:0]
+	for i := 0; i < workers; i++ {
+		roots = append(roots, &node{untried: candidates(s)})
+	}
+	return roots
+}
+
+// child returns the child of n reached by playing move, or nil if
+// it has not been explored.
+func (n *node) child(move game.Move) *node {
+	for _, child := range n.children {
+		if child.move == move {
+			return child
+		}
+	}
+	return nil
+}
+
+// grow runs playouts from s on the tree rooted at root until either
+// budget, if non-zero, or the deadline, if set, is reached, or ctx
+// is done.
+func (m *MCTS) grow(ctx context.Context, s *game.State, root *node, r *rand.Rand, budget int, deadline time.Time) {
+	for i := 0; budget == 0 || i < budget; i++ {
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		m.iterate(s, root, r)
+	}
+}
+
+// iterate runs a single playout: it selects a path down the tree,
+// adds a node for the untried move closest to the center at its
+// end, plays the game out from there, and records the result along
+// the path. The state is restored before returning.
+func (m *MCTS) iterate(s *game.State, root *node, r *rand.Rand) {
+	played := 0
+	defer func() {
+		for ; played > 0; played-- {
+			s.Undo()
+		}
+	}()
+
+	n := root
+	for len(n.untried) == 0 && len(n.children) > 0 {
+		n = m.selectChild(n)
+		s.Apply(n.move)
+		played++
+	}
+
+	if len(n.untried) > 0 {
+		move := n.untried[0]
+		n.untried = n.untried[1:]
+
+		child := &node{move: move, parent: n, side: current(s)}
+		if err := s.Apply(move); err == nil {
+			played++
+			if s.Result() == nil {
+				child.untried = candidates(s)
+			}
+			n.children = append(n.children, child)
+			n = child
+		}
+	}
+
+	policy := m.Policy
+	if policy == nil {
+		policy = LocalPolicy
+	}
+	for s.Result() == nil {
+		if err := s.Apply(policy(s, r)); err != nil {
+			break
+		}
+		played++
+	}
+
+	winner := -1
+	if result := s.Result(); result != nil && result.Winner != "" {
+		winner = indexOf(s, s.SideOf(result.Winner))
+	}
+	for ; n != nil; n = n.parent {
+		n.visits++
+		switch winner {
+		case -1:
+			n.wins += 0.5
+		case n.side:
+			n.wins++
+		}
+	}
+}
+
+// selectChild returns the child of n with the highest upper
+// confidence bound.
+func (m *MCTS) selectChild(n *node) *node {
+	c := m.Exploration
+	if c == 0 {
+		c = math.Sqrt2
+	}
+
+	var best *node
+	bound := math.Inf(-1)
+	for _, child := range n.children {
+		value := child.wins/float64(child.visits) + c*math.Sqrt(math.Log(float64(n.visits))/float64(child.visits))
+		if value > bound {
+			best, bound = child, value
+		}
+	}
+	return best
+}
+
+// report writes the statistics of the most visited candidates.
+func (m *MCTS) report(s *game.State, candidates []Candidate) {
+	total := 0
+	for _, c := range candidates {
+		total += c.Visits
+	}
+	fmt.Fprintf(m.Report, "%s: %d playouts\n", s.Current().Name, total)
+	for i, c := range candidates {
+		if i == reportedMoves {
+			break
+		}
+		fmt.Fprintf(m.Report, "  %-10s %7d visits  %5.1f%%\n", describeMove(c.Move), c.Visits, c.WinRate()*100)
+	}
+}
+
+// ParseMCTS parses a comma-separated list of search settings, e.g.
+// "iterations=50000,time=2s,workers=4,exploration=1.2,policy=random,
+// seed=7,report", where report writes the statistics of every search
+// to standard output. An empty string uses the default settings.
+func ParseMCTS(settings string) (*MCTS, error) {
+	m := &MCTS{}
+	if settings == "" {
+		return m, nil
+	}
+
+	for _, setting := range strings.Split(settings, ",") {
+		key, value, _ := strings.Cut(setting, "=")
+		var err error
+		switch key {
+		case "iterations":
+			m.Iterations, err = strconv.Atoi(value)
+		case "time":
+			m.Duration, err = time.ParseDuration(value)
+		case "workers":
+			m.Workers, err = strconv.Atoi(value)
+		case "exploration":
+			m.Exploration, err = strconv.ParseFloat(value, 64)
+		case "seed":
+			m.Seed, err = strconv.ParseInt(value, 10, 64)
+		case "policy":
+			policy, ok := Policies[value]
+			if !ok {
+				return nil, fmt.Errorf("unknown playout policy %q", value)
+			}
+			m.Policy = policy
+		case "report":
+			m.Report = os.Stdout
+		default:
+			return nil, fmt.Errorf("unknown search setting %q", setting)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid search setting %q: %v", setting, err)
+		}
+	}
+	return m, nil
+}
+
+// current returns the index of the side expected to act next in s.
+func current(s *game.State) int {
+	return indexOf(s, s.Current())
+}
+
+func indexOf(s *game.State, side *game.Side) int {
+	for i, other := range s.Sides() {
+		if other == side {
+			return i
+		}
+	}
+	return -1
+}
+
+func describeMove(m game.Move) string {
+	if m.Choice != "" {
+		return string(m.Choice)
+	}
+	return fmt.Sprintf("%d,%d", m.X, m.Y)
+}
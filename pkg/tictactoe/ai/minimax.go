@@ -0,0 +1,515 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/shape"
+)
+
+const (
+	// winScore is the value of a won position. Wins found sooner
+	// are worth slightly more, so the quickest win is preferred.
+	winScore = 1 << 20
+
+	// neighborhood is how far from existing shapes moves are
+	// considered when searching boards larger than the default.
+	neighborhood = 1
+
+	// largeTime is how long the search is deepened for on boards
+	// larger than the default when neither a depth nor a time is
+	// set, as searching until the end of the game would take too
+	// long.
+	largeTime = 2 * time.Second
+
+	// checkInterval is the number of positions searched between
+	// checks of whether the search should stop.
+	checkInterval = 1024
+
+	// proven is how close to winScore a value must be for the game
+	// to have been searched until its end.
+	proven = winScore - 1000
+
+	// forkDepth is the depth searched when overlooking forks: deep
+	// enough to see a win or a block, but not a double threat.
+	forkDepth = 2
+)
+
+// Minimax chooses moves by searching the game tree with alpha-beta
+// pruning.
+type Minimax struct {
+	// Depth limits how many moves ahead are searched, or zero to
+	// search until the end of the game.
+	Depth int
+	// Time, if set, deepens the search one move at a time, up to
+	// Depth, until it runs out, and plays the best move of the
+	// deepest search completed.
+	Time time.Duration
+
+	// Blunder is the probability of playing a random move instead
+	// of the best one, and MissForks the probability of searching
+	// no deeper than needed to see immediate wins and blocks.
+	Blunder   float64
+	MissForks float64
+	// Rand is the source of the mistakes made, or nil to use the
+	// default source.
+	Rand *rand.Rand
+
+	// Oracle, if set, is asked for the best moves of positions
+	// searched until the end of the game, instead of searching them.
+	Oracle Oracle
+	// Weights, if set, are the weights positions are evaluated with
+	// when the search stops before the end of the game, instead of
+	// DefaultWeights.
+	Weights *Weights
+
+	// mu guards pondered, the result of the last search made while
+	// the other side was to move.
+	mu       sync.Mutex
+	pondered *iteration
+}
+
+// iteration is the result of a search of the position reached by
+// history, deepened to depth.
+type iteration struct {
+	history []game.Entry
+	depth   int
+	move    game.Move
+	value   int
+}
+
+// Oracle knows the best moves of some positions without searching.
+type Oracle interface {
+	// Moves returns the best moves in s, or false if they are not
+	// known.
+	Moves(s *game.State) ([]game.Move, bool)
+}
+
+// Move returns the best move for the current side of s, unless a
+// mistake is made. The state is left unchanged. The search is
+// abandoned with an error once ctx is canceled, and deepened until
+// the deadline of ctx, if it has one.
+func (m *Minimax) Move(ctx context.Context, s *game.State) (game.Move, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s = s.Clone()
+	depth, budget := m.budget(s)
+	if deadline, ok := ctx.Deadline(); ok && (budget == 0 || time.Until(deadline) < budget) {
+		budget = time.Until(deadline)
+	}
+	if s.Phase() == game.PhaseChoice {
+		return m.choose(ctx, s, depth, budget)
+	}
+
+	if m.roll(m.Blunder) {
+		moves := candidates(s)
+		return moves[m.intn(len(moves))], nil
+	}
+	if m.roll(m.MissForks) {
+		if depth == 0 || depth > forkDepth {
+			depth = forkDepth
+		}
+	} else if move, ok := forced(s); ok {
+		return move, nil
+	}
+	if depth == 0 && m.Oracle != nil {
+		if moves, ok := m.Oracle.Moves(s); ok {
+			return moves[0], nil
+		}
+	}
+
+	// a search made while pondering is picked up where it stopped
+	var start *iteration
+	if p := m.pondered; p != nil && sameHistory(p.history, s.History()) && (depth == 0 || p.depth <= depth) {
+		start = p
+	}
+	m.pondered = nil
+
+	result, err := m.think(ctx, s, depth, budget, start)
+	if err != nil {
+		return game.Move{}, err
+	}
+	return result.move, nil
+}
+
+// Ponder searches the position the other side is to move in s,
+// assuming it plays the move the search expects, until ctx is done.
+// If the expected move is played, the next call to Move carries on
+// from the deepest search made.
+func (m *Minimax) Ponder(ctx context.Context, s *game.State) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s = s.Clone()
+	depth, budget := m.budget(s)
+	if s.Phase() != game.PhasePlay || (depth == 0 && m.Oracle != nil && s.Grid.Size() <= 4) {
+		return
+	}
+
+	predicted, err := m.think(ctx, s, depth, budget, nil)
+	if err != nil || s.Apply(predicted.move) != nil || s.Result() != nil || s.Phase() != game.PhasePlay {
+		return
+	}
+	if _, ok := forced(s); ok {
+		return
+	}
+	m.pondered = m.deepen(ctx, s, depth, nil)
+}
+
+// budget returns how deep, and for how long, s is searched.
+func (m *Minimax) budget(s *game.State) (int, time.Duration) {
+	depth, budget := m.Depth, m.Time
+	if depth == 0 && budget == 0 && s.Grid.Size() > 4 {
+		budget = largeTime
+	}
+	return depth, budget
+}
+
+// think searches s up to depth, for no longer than budget if it is
+// set, carrying on from start if it is not nil. A search is always
+// deepened one move at a time when given a budget, and the best
+// move found so far is played once it runs out.
+func (m *Minimax) think(ctx context.Context, s *game.State, depth int, budget time.Duration, start *iteration) (*iteration, error) {
+	if budget == 0 && start == nil {
+		b := &searcher{depth: depth, ctx: ctx, weights: m.weights()}
+		move, value := b.best(s, nil)
+		if b.stopped() {
+			return nil, ctx.Err()
+		}
+		return &iteration{depth: depth, move: move, value: value}, nil
+	}
+
+	limited := ctx
+	if budget > 0 {
+		var cancel context.CancelFunc
+		limited, cancel = context.WithTimeout(ctx, budget)
+		defer cancel()
+	}
+	result := m.deepen(limited, s, depth, start)
+	if err := ctx.Err(); errors.Is(err, context.Canceled) {
+		return nil, err
+	}
+	if result == nil {
+		return &iteration{move: candidates(s)[0]}, nil
+	}
+	return result, nil
+}
+
+// deepen searches s one move deeper at a time, up to depth unless
+// it is zero, until ctx is done or the end of the game is reached,
+// starting after the search made in start if it is not nil. It
+// returns the deepest search completed, or nil if none was.
+func (m *Minimax) deepen(ctx context.Context, s *game.State, depth int, start *iteration) *iteration {
+	last := start
+	history := append([]game.Entry{}, s.History()...)
+	empties := len(s.Legal())
+	for d := 1; depth == 0 || d <= depth; d++ {
+		if last != nil && d <= last.depth {
+			continue
+		}
+		if last != nil && (d > empties || last.value >= proven || last.value <= -proven) {
+			break
+		}
+
+		b := &searcher{depth: d, ctx: ctx, weights: m.weights()}
+		var first *game.Move
+		if last != nil {
+			first = &last.move
+		}
+		move, value := b.best(s, first)
+		if b.stopped() {
+			break
+		}
+		last = &iteration{history: history, depth: d, move: move, value: value}
+	}
+	return last
+}
+
+// sameHistory returns true if both histories hold the same moves,
+// made by the same kinds of shapes.
+func sameHistory(a, b []game.Entry) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Move != b[i].Move || a[i].Kind != b[i].Kind {
+			return false
+		}
+	}
+	return true
+}
+
+// roll returns true with probability p.
+func (m *Minimax) roll(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+	if m.Rand == nil {
+		return rand.Float64() < p
+	}
+	return m.Rand.Float64() < p
+}
+
+func (m *Minimax) intn(n int) int {
+	if m.Rand == nil {
+		return rand.Intn(n)
+	}
+	return m.Rand.Intn(n)
+}
+
+// searcher searches positions up to a given depth, or until the
+// end of the game if it is zero, until its context is done.
+type searcher struct {
+	depth   int
+	ctx     context.Context
+	weights *Weights
+	nodes   int
+	done    bool
+}
+
+// stopped returns true once the context of the search is done. The
+// context is only checked every checkInterval positions.
+func (b *searcher) stopped() bool {
+	if !b.done && b.nodes%checkInterval == 0 {
+		b.done = b.ctx.Err() != nil
+	}
+	return b.done
+}
+
+// best returns the best move for the side to move in s along
+// with its value, searching first, if not nil, before any other.
+func (b *searcher) best(s *game.State, first *game.Move) (game.Move, int) {
+	moves := candidates(s)
+	if first != nil {
+		for i, move := range moves {
+			if move == *first {
+				copy(moves[1:i+1], moves[:i])
+				moves[0] = move
+				break
+			}
+		}
+	}
+
+	best, alpha := moves[0], -math.MaxInt32
+	for _, move := range moves {
+		value := b.child(s, move, 1, alpha, math.MaxInt32)
+		if b.stopped() {
+			break
+		}
+		if value > alpha {
+			best, alpha = move, value
+		}
+	}
+	return best, alpha
+}
+
+// choose picks a side during an opening, preferring whichever kind
+// of shape is better off in the position.
+func (m *Minimax) choose(ctx context.Context, s *game.State, depth int, budget time.Duration) (game.Move, error) {
+	mine := s.Current().Kind
+	s.Apply(game.Move{Choice: game.ChoiceKeep})
+	result, err := m.think(ctx, s, depth, budget, nil)
+	if err != nil {
+		return game.Move{}, err
+	}
+	value := result.value
+	if mine != s.Turn() {
+		value = -value
+	}
+
+	if value < 0 {
+		return game.Move{Choice: game.ChoiceSwap}, nil
+	}
+	return game.Move{Choice: game.ChoiceKeep}, nil
+}
+
+// child plays move in s and returns its value for the side that
+// made it. The same side may move several times in a row while
+// placing handicap stones, so values are only negated when the
+// turn passes to the opponent.
+func (b *searcher) child(s *game.State, move game.Move, ply, alpha, beta int) int {
+	mover := s.Turn()
+	s.Apply(move)
+	defer s.Undo()
+
+	if s.Turn() == mover && s.Result() == nil {
+		return b.search(s, ply, alpha, beta)
+	}
+	return -b.search(s, ply, -beta, -alpha)
+}
+
+// search returns the value of s for the side to move, using
+// negamax with alpha-beta pruning.
+func (b *searcher) search(s *game.State, ply, alpha, beta int) int {
+	b.nodes++
+	if b.stopped() {
+		return 0
+	}
+	if result := s.Result(); result != nil {
+		if result.Winner == "" {
+			return 0
+		}
+		// the game was won by the previous move
+		return -(winScore - ply)
+	}
+	if b.depth > 0 && ply >= b.depth {
+		return evaluate(s, s.Turn(), b.weights)
+	}
+
+	// opening choices are resolved as if the current sides are kept
+	if s.Phase() == game.PhaseChoice {
+		s.Apply(game.Move{Choice: game.ChoiceKeep})
+		defer s.Undo()
+	}
+
+	for _, move := range candidates(s) {
+		if value := b.child(s, move, ply+1, alpha, beta); value > alpha {
+			alpha = value
+		}
+		if alpha >= beta {
+			break
+		}
+	}
+	return alpha
+}
+
+// candidates returns the moves worth searching in s, closest to
+// the center of the grid first. On boards larger than the default,
+// only moves near shapes already placed are considered.
+func candidates(s *game.State) []game.Move {
+	moves := local(s)
+	size := s.Grid.Size()
+
+	center := float64(size-1) / 2
+	distance := func(m game.Move) float64 {
+		return math.Abs(float64(m.X)-center) + math.Abs(float64(m.Y)-center)
+	}
+	for i := 1; i < len(moves); i++ {
+		for j := i; j > 0 && distance(moves[j]) < distance(moves[j-1]); j-- {
+			moves[j], moves[j-1] = moves[j-1], moves[j]
+		}
+	}
+	return moves
+}
+
+// local returns the legal moves in s near shapes already placed,
+// in no particular order. On boards no larger than the default,
+// every legal move is returned.
+func local(s *game.State) []game.Move {
+	legal := s.Legal()
+	size := s.Grid.Size()
+
+	moves := []game.Move{}
+	for _, move := range legal {
+		if size <= 4 || len(s.History()) == 0 || near(s, move, neighborhood) {
+			moves = append(moves, move)
+		}
+	}
+	if len(moves) == 0 {
+		moves = legal
+	}
+	return moves
+}
+
+// near returns true if a shape has been placed within distance
+// cells of move.
+func near(s *game.State, move game.Move, distance int) bool {
+	for y := move.Y - distance; y <= move.Y+distance; y++ {
+		for x := move.X - distance; x <= move.X+distance; x++ {
+			if c := s.Grid.At(x, y); c != nil && !c.Empty() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// evaluate estimates how good s is for kind by adding up the weights
+// of the patterns made by the shapes of kind, less those made by the
+// shapes of the other side.
+func evaluate(s *game.State, kind shape.ShapeKind, w *Weights) int {
+	size := s.Grid.Size()
+	score := 0
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			for _, d := range [][2]int{{1, 0}, {0, 1}, {1, 1}, {-1, 1}} {
+				if p, ok := pattern(s, kind, x, y, d[0], d[1]); ok {
+					score += w[p]
+				}
+				if p, ok := pattern(s, opponent(kind), x, y, d[0], d[1]); ok {
+					score -= w[p]
+				}
+			}
+		}
+	}
+	return score
+}
+
+// pattern returns the pattern made by the shapes of kind on the line
+// of cells starting at x, y in direction dx, dy, as long as kind
+// needs to win. It returns false if the line leaves the grid, holds
+// no shape of kind or holds shapes of the other side.
+func pattern(s *game.State, kind shape.ShapeKind, x, y, dx, dy int) (Pattern, bool) {
+	length := s.WinLength(kind)
+	count := 0
+	first, last := -1, -1
+	for i := 0; i < length; i++ {
+		c := s.Grid.At(x+dx*i, y+dy*i)
+		switch {
+		case c == nil:
+			return 0, false
+		case c.Empty():
+			continue
+		case c.Kind() != kind:
+			return 0, false
+		}
+		count++
+		if first < 0 {
+			first = i
+		}
+		last = i
+	}
+	if count == 0 {
+		return 0, false
+	}
+
+	missing := length - count
+	switch {
+	case missing > 3:
+		return Single, true
+	case missing <= 0:
+		// a full line not winning the game, such as one of a Renju
+		// overline, is worth no more than an open four
+		return OpenFour, true
+	}
+	// patterns of each class are listed open, closed then broken,
+	// from fours to twos
+	p := Pattern(3 * (missing - 1))
+	switch {
+	case last-first+1 > count:
+		p += 2
+	case !empty(s, x+dx*(first-1), y+dy*(first-1)) || !empty(s, x+dx*(last+1), y+dy*(last+1)):
+		p++
+	}
+	return p, true
+}
+
+// empty returns true if x, y is an empty cell of the grid.
+func empty(s *game.State, x, y int) bool {
+	c := s.Grid.At(x, y)
+	return c != nil && c.Empty()
+}
+
+// weights returns the weights positions are evaluated with.
+func (m *Minimax) weights() *Weights {
+	if m.Weights == nil {
+		return &DefaultWeights
+	}
+	return m.Weights
+}
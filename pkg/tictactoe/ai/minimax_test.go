@@ -0,0 +1,110 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/grid"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/shape"
+)
+
+// TestEvaluateHandicapLengths covers lines full of one side's shapes
+// that do not win the game, as when a handicap makes the strong side
+// need more than the length of the variant.
+func TestEvaluateHandicapLengths(t *testing.T) {
+	handicap, err := game.ParseHandicap("weak=2,strong-length=6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := game.NewState(grid.New(game.Gomoku.Size), game.Rules{Variant: game.Gomoku, Handicap: handicap})
+	moves := []game.Move{{X: 0, Y: 0}, {X: 10, Y: 10}, {X: 1, Y: 0}, {X: 12, Y: 12}, {X: 2, Y: 0}, {X: 10, Y: 14}, {X: 3, Y: 0}, {X: 14, Y: 10}, {X: 4, Y: 0}}
+	for _, m := range moves {
+		if err := s.Apply(m); err != nil {
+			t.Fatalf("move %v: %v", m, err)
+		}
+	}
+	if s.Result() != nil {
+		t.Fatalf("five in a row won the game for the side needing six")
+	}
+
+	if score := evaluate(s, shape.CrossShape, &DefaultWeights); score <= 0 {
+		t.Errorf("five in a row of the side needing six scored %d, expected a lead", score)
+	}
+	if _, err := NewMinimax(Beginner, 1).Move(context.Background(), s); err != nil {
+		t.Errorf("unable to move: %v", err)
+	}
+}
+
+func TestPattern(t *testing.T) {
+	tests := []struct {
+		name  string
+		cells []int
+		start int
+		want  Pattern
+	}{
+		{"open four", []int{1, 2, 3, 4}, 0, OpenFour},
+		{"closed four", []int{0, 1, 2, 3}, 0, ClosedFour},
+		{"broken four", []int{1, 2, 4, 5}, 1, BrokenFour},
+		{"open three", []int{2, 3, 4}, 1, OpenThree},
+		{"single", []int{3}, 0, Single},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := game.NewState(grid.New(game.Gomoku.Size), game.Rules{Variant: game.Gomoku})
+			for _, x := range test.cells {
+				s.Grid.At(x, 7).Place(shape.CrossShape)
+			}
+			got, ok := pattern(s, shape.CrossShape, test.start, 7, 1, 0)
+			if !ok || got != test.want {
+				t.Errorf("got %v (%v), expected %v", got, ok, test.want)
+			}
+		})
+	}
+}
+
+// TestExpertNeverLoses plays an expert against every line of play of
+// its opponent on the standard board, moving first and second.
+func TestExpertNeverLoses(t *testing.T) {
+	for starter := 0; starter < 2; starter++ {
+		s := game.NewState(grid.New(game.Standard.Size), game.Rules{Variant: game.Standard})
+		s.Reset(starter)
+		// the expert plays the first side
+		if games := neverLoses(t, NewMinimax(Expert, 1), s, s.Sides()[0].Kind); games == 0 {
+			t.Fatalf("starter %d: no games played", starter)
+		}
+	}
+}
+
+// neverLoses plays every move of the opponent of kind in s, and the
+// move bot chooses for kind, until the games end, and returns the
+// number of games played.
+func neverLoses(t *testing.T, bot Bot, s *game.State, kind shape.ShapeKind) int {
+	t.Helper()
+	if result := s.Result(); result != nil {
+		if result.Winner != "" && result.Winner != kind {
+			t.Errorf("expert lost the game %v", s.History())
+		}
+		return 1
+	}
+
+	moves := s.Legal()
+	if s.Turn() == kind {
+		move, err := bot.Move(context.Background(), s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		moves = []game.Move{move}
+	}
+	games := 0
+	for _, m := range moves {
+		if err := s.Apply(m); err != nil {
+			t.Fatalf("move %v: %v", m, err)
+		}
+		games += neverLoses(t, bot, s, kind)
+		if err := s.Undo(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return games
+}
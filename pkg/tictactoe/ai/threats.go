@@ -0,0 +1,361 @@
+package ai
+
+import (
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/grid"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/shape"
+)
+
+const (
+	// vcfDepth and vctDepth bound how many threats in a row a
+	// threat search makes.
+	vcfDepth = 10
+	vctDepth = 3
+
+	// threatReach is how far from the attacker's shapes a threat
+	// is looked for.
+	threatReach = 2
+
+	// maxThreatNodes bounds the number of positions visited by a
+	// single threat search.
+	maxThreatNodes = 2000
+)
+
+// threatSearch looks for wins by a sequence of forcing threats
+// on its own copy of a game: fours, which must be blocked on the
+// spot, and open threes, which must be blocked before they turn
+// into open fours. Shapes are placed on the grid directly, as
+// the defender may have to answer with moves the rules would
+// otherwise give to the attacker.
+type threatSearch struct {
+	s     *game.State
+	nodes int
+}
+
+func newThreatSearch(s *game.State) *threatSearch {
+	return &threatSearch{s: s.Clone()}
+}
+
+// VCF returns a win for kind in s by victory of continuous fours,
+// as the moves of kind each followed by the only reply blocking
+// it, or nil if none is found. The search assumes kind moves next,
+// and does not account for captures.
+func VCF(s *game.State, kind shape.ShapeKind) []game.Move {
+	return newThreatSearch(s).win(kind, vcfDepth, false)
+}
+
+// VCT returns a win for kind in s by victory of continuous threats,
+// fours or open threes, as the moves of kind each followed by a
+// reply of the other side. Every reply able to block a three, or
+// to counter it with a four, is searched, but only the first is
+// returned.
+func VCT(s *game.State, kind shape.ShapeKind) []game.Move {
+	return newThreatSearch(s).win(kind, vctDepth, true)
+}
+
+func (t *threatSearch) win(kind shape.ShapeKind, depth int, threes bool) []game.Move {
+	moves := []game.Move{}
+	for _, c := range t.attack(kind, opponent(kind), depth, threes) {
+		moves = append(moves, game.Move{X: c.X(), Y: c.Y()})
+	}
+	if len(moves) == 0 {
+		return nil
+	}
+	return moves
+}
+
+// attack returns the cells of a forced win for a against d, or
+// nil if none is found within depth threats.
+func (t *threatSearch) attack(a, d shape.ShapeKind, depth int, threes bool) []*grid.Cell {
+	if depth <= 0 || t.nodes >= maxThreatNodes {
+		return nil
+	}
+	t.nodes++
+
+	if wins := t.winning(a); len(wins) > 0 {
+		return wins[:1]
+	}
+	// a threat that does not block a win of the defender loses
+	blocks := t.winning(d)
+	if len(blocks) > 1 {
+		return nil
+	}
+
+	// fours of the defender may answer any three, and are only
+	// looked for once a three is found
+	var counters []*grid.Cell
+
+	for _, q := range t.area(a, threatReach) {
+		if len(blocks) == 1 && q != blocks[0] || !t.promising(q, a, threes) || !t.allowed(q, a) {
+			continue
+		}
+
+		q.Place(a)
+		replies := t.replies(q, a, threes)
+		if len(replies) > 0 && threes && len(t.completions(q, a)) == 0 {
+			if counters == nil {
+				counters = t.fours(d)
+			}
+			for _, c := range counters {
+				if c.Empty() && !contains(replies, c) {
+					replies = append(replies, c)
+				}
+			}
+		}
+		var line []*grid.Cell
+		if replies != nil {
+			line = t.defend(a, d, replies, depth, threes)
+		}
+		q.Remove()
+
+		if line != nil {
+			return append([]*grid.Cell{q}, line...)
+		}
+	}
+	return nil
+}
+
+// replies returns the moves able to block the threat made at q,
+// an empty list if q already wins, or nil if q makes no threat at
+// all.
+func (t *threatSearch) replies(q *grid.Cell, a shape.ShapeKind, threes bool) []*grid.Cell {
+	fives := t.completions(q, a)
+	switch {
+	case len(fives) > 1:
+		return []*grid.Cell{}
+	case len(fives) == 1:
+		return fives
+	case !threes:
+		return nil
+	}
+
+	if blocks := t.blocks(q, a); len(blocks) > 0 {
+		return blocks
+	}
+	return nil
+}
+
+// promising returns true if enough shapes of kind line up with q
+// for a shape placed on q to make a four or, if threes is true,
+// a three.
+func (t *threatSearch) promising(q *grid.Cell, kind shape.ShapeKind, threes bool) bool {
+	length := t.s.WinLength(kind)
+	needed := length - 2
+	if threes {
+		needed = length - 3
+	}
+
+	for _, dir := range grid.Axes {
+		count := 0
+		for _, c := range t.s.Grid.Axis(q, dir, length-1) {
+			if c.Kind() == kind {
+				count++
+			}
+		}
+		if count >= needed {
+			return true
+		}
+	}
+	return false
+}
+
+// defend returns the rest of a forced win for a once d has tried
+// every reply, or nil if any of the replies holds.
+func (t *threatSearch) defend(a, d shape.ShapeKind, replies []*grid.Cell, depth int, threes bool) []*grid.Cell {
+	var line []*grid.Cell
+	for _, r := range replies {
+		if !t.allowed(r, d) {
+			continue
+		}
+
+		r.Place(d)
+		var rest []*grid.Cell
+		if t.s.Grid.RunThrough(r, t.s.WinLength(d), t.s.Restricted(d)) == nil {
+			rest = t.attack(a, d, depth-1, threes)
+		}
+		r.Remove()
+
+		if rest == nil {
+			return nil
+		}
+		if line == nil {
+			line = append([]*grid.Cell{r}, rest...)
+		}
+	}
+	if line == nil {
+		line = []*grid.Cell{}
+	}
+	return line
+}
+
+// winning returns the cells where kind completes a winning run.
+func (t *threatSearch) winning(kind shape.ShapeKind) []*grid.Cell {
+	wins := []*grid.Cell{}
+	for _, c := range t.area(kind, 1) {
+		c.Place(kind)
+		if t.s.Grid.RunThrough(c, t.s.WinLength(kind), t.s.Restricted(kind)) != nil {
+			wins = append(wins, c)
+		}
+		c.Remove()
+	}
+	return wins
+}
+
+// fours returns the cells where kind threatens to win.
+func (t *threatSearch) fours(kind shape.ShapeKind) []*grid.Cell {
+	found := []*grid.Cell{}
+	for _, c := range t.area(kind, threatReach) {
+		if !t.promising(c, kind, false) || !t.allowed(c, kind) {
+			continue
+		}
+
+		c.Place(kind)
+		if len(t.completions(c, kind)) > 0 {
+			found = append(found, c)
+		}
+		c.Remove()
+	}
+	return found
+}
+
+// completions returns the distinct cells where kind, having just
+// placed a shape on c, completes a winning run through c.
+func (t *threatSearch) completions(c *grid.Cell, kind shape.ShapeKind) []*grid.Cell {
+	found := []*grid.Cell{}
+	for _, dir := range grid.Axes {
+		for _, q := range t.s.Grid.Completions(c, dir, t.s.WinLength(kind), t.s.Restricted(kind)) {
+			if !contains(found, q) {
+				found = append(found, q)
+			}
+		}
+	}
+	return found
+}
+
+// blocks returns the cells able to stop the open threes made by
+// kind placing a shape on c from turning into open fours: every
+// empty cell along the lines of those threes.
+func (t *threatSearch) blocks(c *grid.Cell, kind shape.ShapeKind) []*grid.Cell {
+	length := t.s.WinLength(kind)
+	exact := t.s.Restricted(kind)
+
+	found := []*grid.Cell{}
+	for _, dir := range grid.Axes {
+		cells := t.s.Grid.Axis(c, dir, length-1)
+		three := false
+		for _, r := range cells {
+			if !r.Empty() || !t.allowed(r, kind) {
+				continue
+			}
+
+			r.Place(kind)
+			if count, open := t.s.Grid.Line(c, dir); !exact && count == length-1 && open == 2 {
+				three = true
+			} else if len(t.s.Grid.Completions(c, dir, length, exact)) > 1 {
+				three = true
+			}
+			r.Remove()
+			if three {
+				break
+			}
+		}
+
+		if three {
+			for _, r := range cells {
+				if r.Empty() && !contains(found, r) {
+					found = append(found, r)
+				}
+			}
+		}
+	}
+	return found
+}
+
+// allowed returns true if kind may place a shape on c.
+func (t *threatSearch) allowed(c *grid.Cell, kind shape.ShapeKind) bool {
+	if !c.Empty() {
+		return false
+	}
+	return !t.s.Restricted(kind) || t.s.Grid.Forbidden(c, kind) == grid.Unrestricted
+}
+
+// area returns the empty cells within distance of a shape of kind.
+func (t *threatSearch) area(kind shape.ShapeKind, distance int) []*grid.Cell {
+	cells := []*grid.Cell{}
+	for _, c := range t.s.Grid {
+		if !c.Empty() {
+			continue
+		}
+	next:
+		for y := c.Y() - distance; y <= c.Y()+distance; y++ {
+			for x := c.X() - distance; x <= c.X()+distance; x++ {
+				if n := t.s.Grid.At(x, y); n != nil && n.Kind() == kind {
+					cells = append(cells, c)
+					break next
+				}
+			}
+		}
+	}
+	return cells
+}
+
+// forced returns the move to make in s when a threat search finds
+// a win for the side to move, or a win of the other side that must
+// be blocked. It returns false when neither is found, or when s is
+// not a game threats can be searched in.
+func forced(s *game.State) (game.Move, bool) {
+	if s.Phase() != game.PhasePlay || s.Grid.Size() <= 4 || s.Rules.Variant.Captures {
+		return game.Move{}, false
+	}
+
+	s = s.Clone()
+	me := s.Turn()
+	them := opponent(me)
+	if line := VCF(s, me); line != nil {
+		return line[0], true
+	}
+	if wins := newThreatSearch(s).winning(them); len(wins) > 0 {
+		return game.Move{X: wins[0].X(), Y: wins[0].Y()}, true
+	}
+	if line := VCT(s, me); line != nil {
+		return line[0], true
+	}
+
+	line := VCF(s, them)
+	if line == nil {
+		line = VCT(s, them)
+	}
+	if line == nil {
+		return game.Move{}, false
+	}
+
+	// try to refute the threats on the cells they are made on
+	for _, move := range line {
+		if s.Validate(s.Grid.At(move.X, move.Y)) != nil {
+			continue
+		}
+		s.Apply(move)
+		holds := VCF(s, them) == nil && VCT(s, them) == nil
+		s.Undo()
+		if holds {
+			return move, true
+		}
+	}
+	return game.Move{}, false
+}
+
+func opponent(kind shape.ShapeKind) shape.ShapeKind {
+	if kind == shape.CrossShape {
+		return shape.CircleShape
+	}
+	return shape.CrossShape
+}
+
+func contains(cells []*grid.Cell, c *grid.Cell) bool {
+	for _, other := range cells {
+		if other == c {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// MaxWeight is the largest weight a pattern may be given, keeping the
+// evaluation of any position well below the value of a win.
+const MaxWeight = 100000
+
+// Pattern is a kind of line of cells, as long as needed to win, that
+// holds shapes of a single kind. Fours are one shape short of
+// winning, threes two short and twos three short. Their shapes are
+// broken when empty cells lie between them, and open when unbroken
+// and the cells on both sides of them are empty.
+type Pattern int
+
+const (
+	OpenFour Pattern = iota
+	ClosedFour
+	BrokenFour
+	OpenThree
+	ClosedThree
+	BrokenThree
+	OpenTwo
+	ClosedTwo
+	BrokenTwo
+	// Single is any line further from winning than a two.
+	Single
+
+	patterns
+)
+
+var patternNames = [patterns]string{
+	"open-four",
+	"closed-four",
+	"broken-four",
+	"open-three",
+	"closed-three",
+	"broken-three",
+	"open-two",
+	"closed-two",
+	"broken-two",
+	"single",
+}
+
+func (p Pattern) String() string {
+	return patternNames[p]
+}
+
+// Weights are how much each pattern is worth when evaluating a
+// position, indexed by pattern.
+type Weights [patterns]int
+
+// DefaultWeights make every line worth ten times as much for each
+// shape closer to winning it is.
+var DefaultWeights = Weights{
+	OpenFour:    10000,
+	ClosedFour:  10000,
+	BrokenFour:  10000,
+	OpenThree:   1000,
+	ClosedThree: 1000,
+	BrokenThree: 1000,
+	OpenTwo:     100,
+	ClosedTwo:   100,
+	BrokenTwo:   100,
+	Single:      10,
+}
+
+// MarshalJSON writes the weights as an object mapping the name of
+// each pattern to its weight.
+func (w Weights) MarshalJSON() ([]byte, error) {
+	named := map[string]int{}
+	for p, weight := range w {
+		named[Pattern(p).String()] = weight
+	}
+	return json.Marshal(named)
+}
+
+// UnmarshalJSON reads weights written by MarshalJSON. Patterns left
+// out keep their weight.
+func (w *Weights) UnmarshalJSON(data []byte) error {
+	named := map[string]int{}
+	if err := json.Unmarshal(data, &named); err != nil {
+		return err
+	}
+
+	for name, weight := range named {
+		p, err := patternByName(name)
+		if err != nil {
+			return err
+		}
+		if weight < 0 || weight > MaxWeight {
+			return fmt.Errorf("invalid weight %d of %s: expected a number between 0 and %d", weight, name, MaxWeight)
+		}
+		w[p] = weight
+	}
+	return nil
+}
+
+func patternByName(name string) (Pattern, error) {
+	for p, n := range patternNames {
+		if n == name {
+			return Pattern(p), nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown pattern %q", name)
+}
+
+// LoadWeights reads the weights in the file at path, written by
+// SaveWeights. Patterns the file leaves out keep their default
+// weight.
+func LoadWeights(path string) (*Weights, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	w := DefaultWeights
+	if err := json.Unmarshal(data, &w); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return &w, nil
+}
+
+// SaveWeights writes w to the file at path.
+func SaveWeights(path string, w *Weights) error {
+	data, err := json.MarshalIndent(w, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
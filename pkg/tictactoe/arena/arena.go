@@ -0,0 +1,184 @@
+package arena
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/ai"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/grid"
+)
+
+// Entrant is a player taking part in a tournament.
+type Entrant struct {
+	Name string
+	// New returns the bot playing a single game, drawing its random
+	// choices from seed.
+	New func(seed int64) (ai.Bot, error)
+}
+
+// Config describes the games played in a tournament.
+type Config struct {
+	Variant *game.Variant
+	Opening game.Opening
+
+	// Games is the number of games played by every pair of
+	// entrants, who take turns at playing first.
+	Games int
+	// Workers is the number of games played at once, or zero to
+	// play one per CPU.
+	Workers int
+	// Seed seeds the bots of the first game. Every other game uses
+	// the next seed along, so a tournament can be replayed.
+	Seed int64
+
+	// Progress, if set, is called after every game with the number
+	// of games played so far and the total.
+	Progress func(played, total int)
+}
+
+// Outcome is the result of a game for the entrant that played first.
+type Outcome int
+
+const (
+	Loss Outcome = iota
+	Draw
+	Win
+)
+
+// Score returns the points the outcome is worth, a draw being
+// worth half a win.
+func (o Outcome) Score() float64 {
+	return float64(o) / 2
+}
+
+// Game is a game played in a tournament between the entrants at
+// index First and Second, First playing the first move.
+type Game struct {
+	First   int
+	Second  int
+	Seed    int64
+	Outcome Outcome
+	Moves   int
+}
+
+// Run plays every game of a round-robin between the entrants in
+// parallel, and returns the games in the order they were scheduled.
+func Run(ctx context.Context, config Config, entrants []Entrant) ([]Game, error) {
+	games := schedule(config, len(entrants))
+	workers := config.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan int)
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		played int
+		failed error
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				err := play(ctx, config, entrants, &games[i])
+
+				mu.Lock()
+				if err != nil && failed == nil {
+					failed = err
+					cancel()
+				}
+				played++
+				if err == nil && config.Progress != nil {
+					config.Progress(played, len(games))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := range games {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if failed != nil {
+		return nil, failed
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return games, nil
+}
+
+// schedule lists the games played between every pair of entrants,
+// alternating which of the two plays first.
+func schedule(config Config, entrants int) []Game {
+	games := []Game{}
+	for i := 0; i < entrants; i++ {
+		for j := i + 1; j < entrants; j++ {
+			for n := 0; n < config.Games; n++ {
+				g := Game{First: i, Second: j, Seed: config.Seed + int64(len(games))}
+				if n%2 == 1 {
+					g.First, g.Second = j, i
+				}
+				games = append(games, g)
+			}
+		}
+	}
+	return games
+}
+
+// play plays g out until it is over, recording its outcome.
+func play(ctx context.Context, config Config, entrants []Entrant, g *Game) error {
+	bots := [2]ai.Bot{}
+	for i, entrant := range []int{g.First, g.Second} {
+		bot, err := entrants[entrant].New(g.Seed + int64(i))
+		if err != nil {
+			return fmt.Errorf("%s: %v", entrants[entrant].Name, err)
+		}
+		if closer, ok := bot.(io.Closer); ok {
+			defer closer.Close()
+		}
+		bots[i] = bot
+	}
+
+	s := game.NewState(grid.New(config.Variant.Size), game.Rules{Variant: config.Variant, Opening: config.Opening})
+	for s.Result() == nil {
+		side := 0
+		if s.Current() != s.Sides()[0] {
+			side = 1
+		}
+		move, err := bots[side].Move(ctx, s)
+		if err != nil {
+			return err
+		}
+		if err := s.Apply(move); err != nil {
+			name := entrants[[]int{g.First, g.Second}[side]].Name
+			return fmt.Errorf("%s played an illegal move %v: %v", name, move, err)
+		}
+		g.Moves++
+	}
+
+	switch winner := s.Result().Winner; {
+	case winner == "":
+		g.Outcome = Draw
+	case s.SideOf(winner) == s.Sides()[0]:
+		g.Outcome = Win
+	default:
+		g.Outcome = Loss
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package arena
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// eloIterations is the number of times ratings are refined
+	// towards the ones best explaining the games played.
+	eloIterations = 100
+
+	// z95 is the number of standard errors either side of an
+	// estimate covering 95% of its confidence interval.
+	z95 = 1.96
+)
+
+// Record counts the outcomes of the games played by an entrant.
+type Record struct {
+	Wins   int
+	Draws  int
+	Losses int
+}
+
+func (r Record) Games() int {
+	return r.Wins + r.Draws + r.Losses
+}
+
+// Score returns the share of points won, a draw being worth half a
+// win.
+func (r Record) Score() float64 {
+	if r.Games() == 0 {
+		return 0
+	}
+	return (float64(r.Wins) + float64(r.Draws)/2) / float64(r.Games())
+}
+
+func (r *Record) add(o Outcome) {
+	switch o {
+	case Win:
+		r.Wins++
+	case Draw:
+		r.Draws++
+	default:
+		r.Losses++
+	}
+}
+
+// Standing is how an entrant fared in a tournament.
+type Standing struct {
+	Entrant int
+	Record
+	// Elo is the rating of the entrant relative to the average of
+	// every entrant, and Margin how far either side of it the
+	// rating lies with 95% confidence.
+	Elo    float64
+	Margin float64
+}
+
+// HeadToHead returns the record of entrant a in the games it played
+// against entrant b.
+func HeadToHead(games []Game, a, b int) Record {
+	r := Record{}
+	for _, g := range games {
+		switch {
+		case g.First == a && g.Second == b:
+			r.add(g.Outcome)
+		case g.First == b && g.Second == a:
+			r.add(Win - g.Outcome)
+		}
+	}
+	return r
+}
+
+// Standings returns the record and rating of every entrant, highest
+// rated first. Ratings are the Elo ratings under which the games
+// played are the most likely, with an extra draw between every pair
+// of entrants that met to keep them finite when one always won.
+func Standings(games []Game, entrants int) []Standing {
+	standings := make([]Standing, entrants)
+	// points and played hold the points won by each entrant against
+	// each other, and the number of games they played, including the
+	// extra draw.
+	points := make([][]float64, entrants)
+	played := make([][]float64, entrants)
+	for i := range standings {
+		standings[i].Entrant = i
+		points[i] = make([]float64, entrants)
+		played[i] = make([]float64, entrants)
+	}
+	for _, g := range games {
+		standings[g.First].add(g.Outcome)
+		standings[g.Second].add(Win - g.Outcome)
+		for _, pair := range [][2]int{{g.First, g.Second}, {g.Second, g.First}} {
+			if played[pair[0]][pair[1]] == 0 {
+				points[pair[0]][pair[1]] += 0.5
+				played[pair[0]][pair[1]]++
+			}
+			played[pair[0]][pair[1]]++
+		}
+		points[g.First][g.Second] += g.Outcome.Score()
+		points[g.Second][g.First] += 1 - g.Outcome.Score()
+	}
+
+	ratings := make([]float64, entrants)
+	for n := 0; n < eloIterations; n++ {
+		for i := range ratings {
+			won, expected, slope := 0.0, 0.0, 0.0
+			for j := range ratings {
+				if played[i][j] == 0 {
+					continue
+				}
+				e := expectedScore(ratings[i] - ratings[j])
+				won += points[i][j]
+				expected += played[i][j] * e
+				slope += played[i][j] * e * (1 - e) * math.Ln10 / 400
+			}
+			if slope > 0 {
+				ratings[i] += (won - expected) / slope
+			}
+		}
+	}
+
+	mean := 0.0
+	for _, r := range ratings {
+		mean += r / float64(entrants)
+	}
+	for i := range standings {
+		standings[i].Elo = ratings[i] - mean
+		standings[i].Margin = margin(ratings, i, played[i])
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		return standings[i].Elo > standings[j].Elo
+	})
+	return standings
+}
+
+// expectedScore returns the share of points expected to be won by a
+// player rated diff points higher than its opponent.
+func expectedScore(diff float64) float64 {
+	return 1 / (1 + math.Pow(10, -diff/400))
+}
+
+// margin returns how far either side of its rating the rating of
+// entrant lies with 95% confidence, from the standard error given by
+// the Fisher information of the fitted ratings: how sharply the
+// likelihood of the games played, including the extra draws, falls
+// away from its peak. The extra draws keep it finite but not zero
+// when an entrant always won or always lost.
+func margin(ratings []float64, entrant int, played []float64) float64 {
+	information := 0.0
+	for j := range played {
+		if played[j] == 0 {
+			continue
+		}
+		e := expectedScore(ratings[entrant] - ratings[j])
+		information += played[j] * e * (1 - e) * math.Pow(math.Ln10/400, 2)
+	}
+	if information == 0 {
+		return math.Inf(1)
+	}
+	return z95 / math.Sqrt(information)
+}
@@ -0,0 +1,35 @@
+package arena
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStandingsMargin(t *testing.T) {
+	tests := []struct {
+		name    string
+		outcome Outcome
+	}{
+		{name: "always won", outcome: Win},
+		{name: "always drawn", outcome: Draw},
+		{name: "always lost", outcome: Loss},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			games := []Game{}
+			for i := 0; i < 20; i++ {
+				games = append(games, Game{First: i % 2, Second: 1 - i%2, Outcome: test.outcome})
+			}
+			for _, s := range Standings(games, 2) {
+				if math.IsInf(s.Margin, 0) || math.IsNaN(s.Margin) || s.Margin <= 0 {
+					t.Errorf("entrant %d: expected a finite, positive margin, got %v", s.Entrant, s.Margin)
+				}
+			}
+		})
+	}
+
+	if m := Standings(nil, 2)[0].Margin; !math.IsInf(m, 1) {
+		t.Errorf("expected an infinite margin without games, got %v", m)
+	}
+}
@@ -0,0 +1,231 @@
+package book
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/ai"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/grid"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/record"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/shape"
+)
+
+// Stats are how often a move was played in a position, and how the
+// games it was played in turned out for the side that played it.
+type Stats struct {
+	Games  int `json:"games"`
+	Wins   int `json:"wins"`
+	Draws  int `json:"draws"`
+	Losses int `json:"losses"`
+}
+
+// Score returns the share of points won by the move, a draw being
+// worth half a win.
+func (s Stats) Score() float64 {
+	return (float64(s.Wins) + float64(s.Draws)/2) / float64(s.Games)
+}
+
+// Book holds the moves played in the openings of recorded games.
+// Positions equal under a rotation or reflection of the board share
+// an entry.
+type Book struct {
+	// Positions maps each position, as written by key, to the moves
+	// played in it, by the index of their cell in the orientation
+	// the key is written in.
+	Positions map[string]map[int]*Stats `json:"positions"`
+}
+
+func New() *Book {
+	return &Book{Positions: make(map[string]map[int]*Stats)}
+}
+
+// Len returns the number of positions in the book.
+func (b *Book) Len() int {
+	return len(b.Positions)
+}
+
+// Add adds the first plies moves of g to the book. Games played with
+// a handicap are left out, as their positions are not played under
+// the usual rules.
+func (b *Book) Add(g *record.Game, plies int) error {
+	if g.Handicap != (game.Handicap{}) {
+		return nil
+	}
+
+	_, err := g.Replay(func(s *game.State, m record.Move) {
+		if len(s.History()) >= plies || s.Phase() != game.PhasePlay || m.Choice != "" {
+			return
+		}
+		key, orient := key(s)
+		moves := b.Positions[key]
+		if moves == nil {
+			moves = make(map[int]*Stats)
+			b.Positions[key] = moves
+		}
+		stats := moves[orient[m.Y*s.Grid.Size()+m.X]]
+		if stats == nil {
+			stats = &Stats{}
+			moves[orient[m.Y*s.Grid.Size()+m.X]] = stats
+		}
+
+		stats.Games++
+		switch g.Winner {
+		case "":
+			stats.Draws++
+		case m.Side:
+			stats.Wins++
+		default:
+			stats.Losses++
+		}
+	})
+	return err
+}
+
+// Candidate is a move of the book, along with how it fared.
+type Candidate struct {
+	Move game.Move
+	Stats
+}
+
+// Moves returns the legal moves the book holds for s, most played
+// first.
+func (b *Book) Moves(s *game.State) []Candidate {
+	if s.Phase() != game.PhasePlay {
+		return nil
+	}
+	key, orient := key(s)
+	moves := b.Positions[key]
+	if len(moves) == 0 {
+		return nil
+	}
+
+	candidates := []Candidate{}
+	for _, m := range s.Legal() {
+		if stats, ok := moves[orient[m.Y*s.Grid.Size()+m.X]]; ok {
+			candidates = append(candidates, Candidate{Move: m, Stats: *stats})
+		}
+	}
+	for i := 1; i < len(candidates); i++ {
+		for j := i; j > 0 && candidates[j].Games > candidates[j-1].Games; j-- {
+			candidates[j], candidates[j-1] = candidates[j-1], candidates[j]
+		}
+	}
+	return candidates
+}
+
+// Choose picks one of the moves of the book for s at random, each
+// as likely as it was often played. Moves that never won nor drew
+// are left out unless no other move did. It returns false if the
+// book holds no move for s.
+func (b *Book) Choose(s *game.State, r *rand.Rand) (game.Move, bool) {
+	candidates := b.Moves(s)
+	scored := []Candidate{}
+	for _, c := range candidates {
+		if c.Wins+c.Draws > 0 {
+			scored = append(scored, c)
+		}
+	}
+	if len(scored) > 0 {
+		candidates = scored
+	}
+
+	total := 0
+	for _, c := range candidates {
+		total += c.Games
+	}
+	if total == 0 {
+		return game.Move{}, false
+	}
+	n := r.Intn(total)
+	for _, c := range candidates {
+		if n < c.Games {
+			return c.Move, true
+		}
+		n -= c.Games
+	}
+	return game.Move{}, false
+}
+
+// key returns the key of the position of s, the smallest of the ways
+// of writing it under each symmetry of the board, along with the cell
+// each cell lands on in that orientation. Positions of different
+// variants, or with a different side to move or number of captures,
+// have different keys.
+func key(s *game.State) (string, []int) {
+	prefix := fmt.Sprintf("%s:%s:%d:%d:", s.Rules.Variant.Name, strings.ToLower(string(s.Turn())), s.Captures(shape.CrossShape), s.Captures(shape.CircleShape))
+	best, orient := "", []int(nil)
+	for _, sym := range grid.Symmetries(s.Grid.Size()) {
+		cells := make([]byte, len(s.Grid))
+		for i, cell := range s.Grid {
+			c := byte('.')
+			if !cell.Empty() {
+				c = strings.ToLower(string(cell.Kind()))[0]
+			}
+			cells[sym[i]] = c
+		}
+		if k := prefix + string(cells); orient == nil || k < best {
+			best, orient = k, sym
+		}
+	}
+	return best, orient
+}
+
+// Save writes the book to the file at path.
+func (b *Book) Save(path string) error {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Load reads the book in the file at path.
+func Load(path string) (*Book, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	b := New()
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return b, nil
+}
+
+// Bot plays the moves of a book while it has any, and those of
+// another bot after that.
+type Bot struct {
+	Book *Book
+	Bot  ai.Bot
+	// Rand is the source of the book moves chosen.
+	Rand *rand.Rand
+}
+
+func (b *Bot) Move(ctx context.Context, s *game.State) (game.Move, error) {
+	if m, ok := b.Book.Choose(s, b.Rand); ok {
+		return m, nil
+	}
+	return b.Bot.Move(ctx, s)
+}
+
+// Ponder lets the other bot ponder, if it can.
+func (b *Bot) Ponder(ctx context.Context, s *game.State) {
+	if p, ok := b.Bot.(ai.Ponderer); ok {
+		p.Ponder(ctx, s)
+	}
+}
+
+// Close closes the other bot, if it needs closing.
+func (b *Bot) Close() error {
+	if closer, ok := b.Bot.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
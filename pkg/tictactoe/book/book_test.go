@@ -0,0 +1,138 @@
+package book
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/grid"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/record"
+)
+
+// played returns the state reached by making moves in a new game of
+// the standard variant with the given opening and handicap.
+func played(t *testing.T, opening game.Opening, handicap game.Handicap, moves ...game.Move) *game.State {
+	t.Helper()
+	s := game.NewState(grid.New(game.Standard.Size), game.Rules{Variant: game.Standard, Opening: opening, Handicap: handicap})
+	for _, m := range moves {
+		if err := s.Apply(m); err != nil {
+			t.Fatalf("move %v: %v", m, err)
+		}
+	}
+	return s
+}
+
+// build returns a book of the given games, each a list of moves of
+// the standard variant without an opening.
+func build(t *testing.T, games ...[]game.Move) *Book {
+	t.Helper()
+	b := New()
+	for _, moves := range games {
+		if err := b.Add(record.FromState(played(t, game.NoOpening, game.Handicap{}, moves...)), 9); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return b
+}
+
+// find returns the stats of move among the candidates of the book
+// for s.
+func find(b *Book, s *game.State, move game.Move) (Stats, bool) {
+	for _, c := range b.Moves(s) {
+		if c.Move == move {
+			return c.Stats, true
+		}
+	}
+	return Stats{}, false
+}
+
+func TestAddSymmetric(t *testing.T) {
+	// the second game is the first turned half way around
+	b := build(t,
+		[]game.Move{{X: 0, Y: 0}, {X: 1, Y: 0}},
+		[]game.Move{{X: 2, Y: 2}, {X: 1, Y: 2}},
+	)
+
+	tests := []struct {
+		name  string
+		first game.Move
+		reply game.Move
+	}{
+		{name: "as played first", first: game.Move{X: 0, Y: 0}, reply: game.Move{X: 1, Y: 0}},
+		{name: "as played second", first: game.Move{X: 2, Y: 2}, reply: game.Move{X: 1, Y: 2}},
+		{name: "never played", first: game.Move{X: 2, Y: 0}, reply: game.Move{X: 2, Y: 1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stats, ok := find(b, played(t, game.NoOpening, game.Handicap{}, test.first), test.reply)
+			if !ok || stats.Games != 2 {
+				t.Errorf("expected %v to have been played twice, got %+v", test.reply, stats)
+			}
+		})
+	}
+}
+
+func TestAddPieSwap(t *testing.T) {
+	// the second player swaps to X, and the first player, now O,
+	// wins down the left column
+	moves := []game.Move{{X: 1, Y: 1}, {Choice: game.ChoiceSwap}, {X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}, {X: 2, Y: 2}, {X: 0, Y: 2}}
+	s := played(t, game.Pie, game.Handicap{}, moves...)
+	g := record.FromState(s)
+	if g.Winner != s.Sides()[0].Name {
+		t.Fatalf("expected %s to win, got %q", s.Sides()[0].Name, g.Winner)
+	}
+	b := New()
+	if err := b.Add(g, 9); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		before []game.Move
+		move   game.Move
+		want   Stats
+	}{
+		{name: "winning O", before: moves[:2], move: moves[2], want: Stats{Games: 1, Wins: 1}},
+		{name: "losing X", before: moves[:3], move: moves[3], want: Stats{Games: 1, Losses: 1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stats, _ := find(b, played(t, game.Pie, game.Handicap{}, test.before...), test.move)
+			if stats != test.want {
+				t.Errorf("expected %+v, got %+v", test.want, stats)
+			}
+		})
+	}
+}
+
+func TestChoose(t *testing.T) {
+	lost := []game.Move{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 2, Y: 0}, {X: 2, Y: 2}}
+	drawn := []game.Move{{X: 0, Y: 0}, {X: 1, Y: 1}}
+	b := build(t, lost, lost, lost, drawn)
+
+	s := played(t, game.NoOpening, game.Handicap{}, game.Move{X: 0, Y: 0})
+	if stats, ok := find(b, s, game.Move{X: 1, Y: 0}); !ok || stats.Losses != 3 {
+		t.Fatalf("expected the losing move to be in the book, got %+v", stats)
+	}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		if m, ok := b.Choose(s, r); !ok || m != (game.Move{X: 1, Y: 1}) {
+			t.Fatalf("expected the drawn move to be chosen, got %v", m)
+		}
+	}
+
+	if _, ok := b.Choose(played(t, game.NoOpening, game.Handicap{}, game.Move{X: 1, Y: 1}), r); ok {
+		t.Error("expected no move for a position not in the book")
+	}
+}
+
+func TestAddHandicap(t *testing.T) {
+	b := New()
+	s := played(t, game.NoOpening, game.Handicap{Center: true}, game.Move{X: 0, Y: 0})
+	if err := b.Add(record.FromState(s), 9); err != nil {
+		t.Fatal(err)
+	}
+	if b.Len() != 0 {
+		t.Errorf("expected games with a handicap to be left out, got %d positions", b.Len())
+	}
+}
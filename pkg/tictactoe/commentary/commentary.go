@@ -0,0 +1,179 @@
+package commentary
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/ai"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/grid"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/shape"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/solver"
+)
+
+// threat is an empty cell where a side completes a winning line,
+// along with the direction of that line.
+type threat struct {
+	cell *grid.Cell
+	dir  grid.Direction
+}
+
+// Comment returns a line of commentary on move, about to be made in
+// before: the wins it makes, misses or gives away, and the threats
+// it blocks or creates. Positions small enough to be solved by the
+// oracle, if set, are also checked for blunders. It returns an empty
+// string if there is nothing to say about the move.
+func Comment(before *game.State, move game.Move, oracle *solver.Oracle) string {
+	if move.Choice != "" || before.Phase() == game.PhaseOver {
+		return ""
+	}
+	kind, other := before.Turn(), opponent(before.Turn())
+	after := before.Clone()
+	if err := after.Apply(move); err != nil {
+		return ""
+	}
+
+	if result := after.Result(); result != nil {
+		switch {
+		case result.Winner == "":
+			return fmt.Sprintf("%s fills the last cell - a draw", kind)
+		case result.Run == nil:
+			return fmt.Sprintf("%s wins by captures", kind)
+		}
+		return fmt.Sprintf("%s completes the %s and wins", kind, line(result.Run.Dir))
+	}
+
+	size := before.Grid.Size()
+	missed := threats(before, kind)
+	blocked := threats(before, other)
+	open := threats(after, other)
+	made := threats(after, kind)
+
+	clauses := []string{}
+	if len(missed) > 0 {
+		clauses = append(clauses, fmt.Sprintf("misses the win on %s", cells(missed, size)))
+	}
+	for _, t := range blocked {
+		if t.cell.X() == move.X && t.cell.Y() == move.Y {
+			clauses = append(clauses, fmt.Sprintf("blocks the %s", line(t.dir)))
+			break
+		}
+	}
+	if len(open) > 0 && after.Turn() == other {
+		clauses = append(clauses, fmt.Sprintf("fails to block the %s - losing", line(open[0].dir)))
+		return sentence(kind, clauses)
+	}
+	switch {
+	case len(made) > 1:
+		clauses = append(clauses, fmt.Sprintf("creates a fork on %s", cells(made, size)))
+	case len(made) == 1:
+		clauses = append(clauses, fmt.Sprintf("threatens to win on %s", cells(made, size)))
+	}
+
+	if verdict, ok := solve(before, after, oracle); ok {
+		if verdict != "" && len(missed) == 0 {
+			clauses = append(clauses, verdict)
+		}
+	} else if len(made) == 0 && after.Turn() == other {
+		if ai.VCF(after, other) != nil {
+			clauses = append(clauses, "allows a forced win by continuous fours - losing")
+		} else if vcf := ai.VCF(before, kind); len(vcf) > 0 && vcf[0] == move {
+			clauses = append(clauses, "starts a forced win by continuous fours")
+		}
+	}
+	return sentence(kind, clauses)
+}
+
+// solve compares the values of the positions before and after the
+// move with perfect play, returning what the move gave away, if
+// anything. It returns false if the positions cannot be solved.
+func solve(before, after *game.State, oracle *solver.Oracle) (string, bool) {
+	if oracle == nil {
+		return "", false
+	}
+	p, err := solver.FromState(before)
+	if err != nil || p.Size > solver.MaxSize {
+		return "", false
+	}
+	q, err := solver.FromState(after)
+	if err != nil {
+		return "", false
+	}
+	was, _, err := oracle.Evaluate(p)
+	if err != nil {
+		return "", false
+	}
+	now, _, err := oracle.Evaluate(q)
+	if err != nil {
+		return "", false
+	}
+	if p.Turn == q.Turn {
+		now = -now
+	}
+
+	switch {
+	case -now >= was:
+		return "", true
+	case -now == solver.Loss:
+		return "blunders - losing", true
+	}
+	return "blunders away the win", true
+}
+
+// threats returns the empty cells where kind completes a winning
+// line in s, without changing s.
+func threats(s *game.State, kind shape.ShapeKind) []threat {
+	s = s.Clone()
+	found := []threat{}
+	for _, c := range s.Grid {
+		if !c.Empty() {
+			continue
+		}
+		c.Place(kind)
+		if run := s.Grid.RunThrough(c, s.WinLength(kind), s.Restricted(kind)); run != nil {
+			found = append(found, threat{cell: c, dir: run.Dir})
+		}
+		c.Remove()
+	}
+	return found
+}
+
+// Cell returns the name of the cell at x, y on a board of the given
+// size: its column as a letter from the left, followed by its row as
+// a number from the bottom.
+func Cell(x, y, size int) string {
+	return fmt.Sprintf("%c%d", 'a'+x, size-y)
+}
+
+func cells(threats []threat, size int) string {
+	names := []string{}
+	for _, t := range threats {
+		names = append(names, Cell(t.cell.X(), t.cell.Y(), size))
+	}
+	return strings.Join(names, "/")
+}
+
+// line returns the name of a line running in direction d.
+func line(d grid.Direction) string {
+	switch d {
+	case grid.Left, grid.Right:
+		return "row"
+	case grid.Top, grid.Bottom:
+		return "column"
+	}
+	return "diagonal"
+}
+
+func sentence(kind shape.ShapeKind, clauses []string) string {
+	if len(clauses) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s %s", kind, strings.Join(clauses, ", "))
+}
+
+func opponent(kind shape.ShapeKind) shape.ShapeKind {
+	if kind == shape.CrossShape {
+		return shape.CircleShape
+	}
+	return shape.CrossShape
+}
@@ -0,0 +1,81 @@
+package commentary
+
+import (
+	"testing"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/grid"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/solver"
+)
+
+func TestComment(t *testing.T) {
+	type comment struct {
+		move game.Move
+		want string
+	}
+	tests := []struct {
+		name     string
+		oracle   bool
+		comments []comment
+	}{
+		{
+			name:   "blunders",
+			oracle: true,
+			comments: []comment{
+				{game.Move{X: 1, Y: 1}, ""},
+				{game.Move{X: 1, Y: 0}, "O blunders - losing"},
+				{game.Move{X: 0, Y: 0}, "X threatens to win on c1"},
+				{game.Move{X: 2, Y: 2}, "O blocks the diagonal"},
+				{game.Move{X: 2, Y: 0}, "X threatens to win on a1, blunders away the win"},
+				{game.Move{X: 0, Y: 2}, "O blocks the diagonal, threatens to win on b1"},
+				{game.Move{X: 0, Y: 1}, "X fails to block the row - losing"},
+				{game.Move{X: 1, Y: 2}, "O completes the row and wins"},
+			},
+		},
+		{
+			name: "fork without an oracle",
+			comments: []comment{
+				{game.Move{X: 1, Y: 1}, ""},
+				{game.Move{X: 1, Y: 0}, "O allows a forced win by continuous fours - losing"},
+				{game.Move{X: 0, Y: 0}, "X threatens to win on c1"},
+				{game.Move{X: 2, Y: 2}, "O blocks the diagonal, allows a forced win by continuous fours - losing"},
+				{game.Move{X: 0, Y: 2}, "X creates a fork on c3/a2"},
+				{game.Move{X: 2, Y: 0}, "O blocks the diagonal, fails to block the column - losing"},
+				{game.Move{X: 0, Y: 1}, "X completes the column and wins"},
+			},
+		},
+		{
+			name:   "draw",
+			oracle: true,
+			comments: []comment{
+				{game.Move{X: 1, Y: 1}, ""},
+				{game.Move{X: 0, Y: 0}, ""},
+				{game.Move{X: 2, Y: 2}, ""},
+				{game.Move{X: 0, Y: 2}, "O threatens to win on a2"},
+				{game.Move{X: 0, Y: 1}, "X blocks the column, threatens to win on c2"},
+				{game.Move{X: 2, Y: 1}, "O blocks the row"},
+				{game.Move{X: 1, Y: 0}, "X threatens to win on b1"},
+				{game.Move{X: 1, Y: 2}, "O blocks the column"},
+				{game.Move{X: 2, Y: 0}, "X fills the last cell - a draw"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var oracle *solver.Oracle
+			if test.oracle {
+				oracle = solver.NewOracle()
+			}
+			s := game.NewState(grid.New(game.Standard.Size), game.Rules{Variant: game.Standard, Opening: game.NoOpening})
+			for i, c := range test.comments {
+				if got := Comment(s, c.move, oracle); got != c.want {
+					t.Errorf("move %d %v: expected %q, got %q", i+1, c.move, c.want, got)
+				}
+				if err := s.Apply(c.move); err != nil {
+					t.Fatalf("move %d %v: %v", i+1, c.move, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+)
+
+const (
+	// DefaultMoveTime is how long engines are given to think about
+	// each move unless told otherwise.
+	DefaultMoveTime = time.Second
+
+	// startTimeout is how long an engine is given to introduce
+	// itself once started, and moveGrace how long past its move
+	// time it is given to reply before it is killed.
+	startTimeout = 5 * time.Second
+	moveGrace    = 2 * time.Second
+	// quitTimeout is how long an engine is given to exit once told
+	// to quit.
+	quitTimeout = time.Second
+)
+
+var ErrUnresponsive = errors.New("engine stopped responding")
+
+// Engine is a computer player running as a separate process, which
+// the game talks to over the engine protocol.
+type Engine struct {
+	// Name is the name the engine introduced itself with.
+	Name string
+	// MoveTime is how long the engine is given to think about each
+	// move.
+	MoveTime time.Duration
+	// Info, if set, is called with every info line sent by the
+	// engine.
+	Info func(line string)
+
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	lines  chan string
+	killed bool
+
+	// mu serializes moves, and guards the game and moves last set
+	// up in the engine.
+	mu      sync.Mutex
+	rules   string
+	history []game.Entry
+}
+
+// Start starts the engine at path with the given arguments, and
+// waits for it to introduce itself.
+func Start(path string, args ...string) (*Engine, error) {
+	cmd := exec.Command(path, args...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	e := &Engine{
+		MoveTime: DefaultMoveTime,
+		cmd:      cmd,
+		stdin:    stdin,
+		lines:    make(chan string),
+	}
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			e.lines <- scanner.Text()
+		}
+		close(e.lines)
+	}()
+
+	if err := e.send("tictactoe"); err != nil {
+		e.kill()
+		return nil, err
+	}
+	deadline := time.After(startTimeout)
+	for {
+		line, err := e.receive(context.Background(), deadline)
+		if err != nil {
+			e.kill()
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		if name := strings.TrimPrefix(line, "id name "); name != line {
+			e.Name = name
+		}
+		if line == "tictactoeok" {
+			break
+		}
+	}
+	if e.Name == "" {
+		e.Name = path
+	}
+	return e, nil
+}
+
+// Launch starts the engine described by command, which is the path
+// to the engine followed by its arguments, separated by spaces. The
+// path may be preceded by settings: "movetime=<duration>" sets how
+// long the engine thinks about each move, and "report" writes every
+// info line it sends to standard output.
+func Launch(command string) (*Engine, error) {
+	fields := strings.Fields(command)
+	moveTime, report := DefaultMoveTime, false
+	for len(fields) > 0 {
+		key, value, _ := strings.Cut(fields[0], "=")
+		if key == "movetime" {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid engine setting %q: expected a duration such as 500ms", fields[0])
+			}
+			moveTime = d
+		} else if key == "report" {
+			report = true
+		} else {
+			break
+		}
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("missing engine command in %q", command)
+	}
+
+	e, err := Start(fields[0], fields[1:]...)
+	if err != nil {
+		return nil, err
+	}
+	e.MoveTime = moveTime
+	if report {
+		e.Info = func(line string) {
+			fmt.Printf("%s: %s\n", e.Name, line)
+		}
+	}
+	return e, nil
+}
+
+// SetOption sets an option of the engine, such as its seed.
+func (e *Engine) SetOption(name, value string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.send(fmt.Sprintf("setoption name %s value %s", name, value))
+}
+
+// Supports returns an error unless games played with rules can be
+// played by engines, which are not told about handicaps.
+func Supports(rules game.Rules) error {
+	if rules.Handicap != (game.Handicap{}) {
+		return errors.New("engines cannot play games with a handicap")
+	}
+	return nil
+}
+
+// Move asks the engine for its move in s, giving it MoveTime to
+// think. If ctx is done first, the engine is told to stop, and its
+// move is discarded. An engine failing to reply in time is killed.
+func (e *Engine) Move(ctx context.Context, s *game.State) (game.Move, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := Supports(s.Rules); err != nil {
+		return game.Move{}, fmt.Errorf("%s: %v", e.Name, err)
+	}
+
+	// a new game is started whenever s does not follow from the
+	// position last sent
+	rules := fmt.Sprintf("%s %s", s.Rules.Variant.Name, s.Rules.Opening)
+	history := s.History()
+	if rules != e.rules || len(history) < len(e.history) || !sameMoves(e.history, history[:len(e.history)]) {
+		if err := e.send("newgame " + rules); err != nil {
+			return game.Move{}, err
+		}
+		e.rules = rules
+	}
+	e.history = append([]game.Entry{}, history...)
+
+	position := "position"
+	if len(history) > 0 {
+		moves := []string{}
+		for _, entry := range history {
+			moves = append(moves, FormatMove(entry.Move))
+		}
+		position += " moves " + strings.Join(moves, " ")
+	}
+	if err := e.send(position); err != nil {
+		return game.Move{}, err
+	}
+	if err := e.send(fmt.Sprintf("go movetime %d", e.MoveTime.Milliseconds())); err != nil {
+		return game.Move{}, err
+	}
+
+	move, err := e.bestMove(ctx, time.After(e.MoveTime+moveGrace))
+	if ctx.Err() != nil {
+		// the move was cut short, and the engine must still reply
+		// before it can be asked for another
+		e.send("stop")
+		if _, err := e.bestMove(context.Background(), time.After(moveGrace)); err != nil {
+			e.kill()
+		}
+		return game.Move{}, ctx.Err()
+	}
+	if errors.Is(err, ErrUnresponsive) {
+		e.kill()
+	}
+	if err != nil {
+		return game.Move{}, fmt.Errorf("%s: %v", e.Name, err)
+	}
+	return move, nil
+}
+
+// bestMove waits for the engine to reply with its move, passing any
+// info lines sent in the meantime on to Info.
+func (e *Engine) bestMove(ctx context.Context, deadline <-chan time.Time) (game.Move, error) {
+	for {
+		line, err := e.receive(ctx, deadline)
+		if err != nil {
+			return game.Move{}, err
+		}
+		switch fields := strings.Fields(line); {
+		case len(fields) > 0 && fields[0] == "info":
+			if e.Info != nil {
+				e.Info(strings.TrimPrefix(line, "info "))
+			}
+		case len(fields) > 1 && fields[0] == "bestmove" && fields[1] == "none":
+			return game.Move{}, errors.New("no move to play")
+		case len(fields) > 1 && fields[0] == "bestmove":
+			return ParseMove(fields[1])
+		}
+	}
+}
+
+// receive returns the next line sent by the engine, unless ctx is
+// done or the deadline passes first.
+func (e *Engine) receive(ctx context.Context, deadline <-chan time.Time) (string, error) {
+	select {
+	case line, ok := <-e.lines:
+		if !ok {
+			return "", fmt.Errorf("engine exited")
+		}
+		return line, nil
+	case <-deadline:
+		return "", ErrUnresponsive
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (e *Engine) send(command string) error {
+	_, err := fmt.Fprintln(e.stdin, command)
+	return err
+}
+
+// kill ends the engine process without waiting for it to quit.
+func (e *Engine) kill() {
+	if e.killed {
+		return
+	}
+	e.killed = true
+	e.cmd.Process.Kill()
+	go e.cmd.Wait()
+	go e.drain()
+}
+
+// drain discards whatever the engine sends until it exits.
+func (e *Engine) drain() {
+	for range e.lines {
+	}
+}
+
+// Close tells the engine to quit, and kills it if it does not.
+func (e *Engine) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.killed {
+		return nil
+	}
+
+	e.send("quit")
+	e.stdin.Close()
+	go e.drain()
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- e.cmd.Wait()
+	}()
+	select {
+	case err := <-exited:
+		return err
+	case <-time.After(quitTimeout):
+		e.cmd.Process.Kill()
+		return <-exited
+	}
+}
+
+// sameMoves returns true if both histories hold the same moves.
+func sameMoves(a, b []game.Entry) bool {
+	for i := range a {
+		if a[i].Move != b[i].Move {
+			return false
+		}
+	}
+	return true
+}
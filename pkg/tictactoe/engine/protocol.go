@@ -0,0 +1,55 @@
+// Package engine lets computer players run as separate processes,
+// written in any language, talking to the game over their standard
+// input and output one line at a time.
+//
+// The game, acting as the host, sends commands to the engine:
+//
+//	tictactoe                      start talking; the engine replies with
+//	                               "id name <name>" and "tictactoeok"
+//	setoption name <n> value <v>   set an option, such as the seed
+//	isready                        the engine replies with "readyok"
+//	newgame <variant> [<opening>]  start a game of the given variant
+//	position [moves <move>...]     set up the position reached by playing
+//	                               the moves from the start of the game
+//	go movetime <ms>               think for about ms milliseconds, then
+//	                               reply with "bestmove <move>", or with
+//	                               "bestmove none" if the game is over
+//	stop                           reply with "bestmove <move>" now
+//	quit                           exit
+//
+// While thinking, the engine may send "info" lines made of pairs of
+// names and values, e.g. "info depth 4 time 120". Moves are written
+// as the zero-based column and row of a cell, e.g. "7,7", or as an
+// opening choice with spaces replaced by dashes, e.g. "place-two".
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+)
+
+// FormatMove returns m as written in the protocol.
+func FormatMove(m game.Move) string {
+	if m.Choice != "" {
+		return strings.ReplaceAll(string(m.Choice), " ", "-")
+	}
+	return fmt.Sprintf("%d,%d", m.X, m.Y)
+}
+
+// ParseMove parses a move written by FormatMove.
+func ParseMove(move string) (game.Move, error) {
+	if !strings.Contains(move, ",") {
+		if move == "" {
+			return game.Move{}, fmt.Errorf("missing move")
+		}
+		return game.Move{Choice: game.Choice(strings.ReplaceAll(move, "-", " "))}, nil
+	}
+
+	m := game.Move{}
+	if _, err := fmt.Sscanf(move, "%d,%d", &m.X, &m.Y); err != nil {
+		return game.Move{}, fmt.Errorf("invalid move %q: %v", move, err)
+	}
+	return m, nil
+}
@@ -0,0 +1,233 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/ai"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/grid"
+)
+
+// Serve runs an engine named name, answering the commands read from
+// r on w until r is exhausted or told to quit. The moves of each game
+// are chosen by a bot returned by newBot, given the seed last set
+// with "setoption name seed".
+func Serve(r io.Reader, w io.Writer, name string, newBot func(seed int64) (ai.Bot, error)) error {
+	out := &writer{w: w}
+	var (
+		seed    int64
+		variant = game.Standard
+		opening = game.NoOpening
+		bot     ai.Bot
+		state   *game.State
+		search  *search
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "tictactoe":
+			out.printf("id name %s", name)
+			out.printf("tictactoeok")
+		case "isready":
+			out.printf("readyok")
+		case "setoption":
+			if len(fields) == 5 && fields[1] == "name" && fields[2] == "seed" && fields[3] == "value" {
+				n, err := strconv.ParseInt(fields[4], 10, 64)
+				if err != nil {
+					out.printf("info string invalid seed %q", fields[4])
+					continue
+				}
+				seed, bot = n, nil
+			}
+		case "newgame":
+			if len(fields) < 2 {
+				out.printf("info string missing variant")
+				continue
+			}
+			v, err := game.VariantByName(fields[1])
+			if err != nil {
+				out.printf("info string %v", err)
+				continue
+			}
+			o := game.NoOpening
+			if len(fields) > 2 {
+				if o, err = game.OpeningByName(fields[2]); err != nil {
+					out.printf("info string %v", err)
+					continue
+				}
+			}
+			variant, opening, bot, state = v, o, nil, nil
+		case "position":
+			s, err := setUp(variant, opening, fields[1:])
+			if err != nil {
+				out.printf("info string %v", err)
+				continue
+			}
+			state = s
+		case "go":
+			if search != nil {
+				search.stop()
+			}
+			if state == nil {
+				state = game.NewState(grid.New(variant.Size), game.Rules{Variant: variant, Opening: opening})
+			}
+			if state.Result() != nil || len(state.Legal()) == 0 {
+				search = nil
+				out.printf("info string game is over")
+				out.printf("bestmove none")
+				continue
+			}
+			if bot == nil {
+				b, err := newBot(seed)
+				if err != nil {
+					return err
+				}
+				bot = b
+			}
+			search = start(bot, state.Clone(), moveTime(fields[1:]), out)
+		case "stop":
+			if search != nil {
+				search.stop()
+			}
+		case "quit":
+			if search != nil {
+				search.stop()
+			}
+			return nil
+		default:
+			out.printf("info string unknown command %q", fields[0])
+		}
+	}
+	if search != nil {
+		search.stop()
+	}
+	return scanner.Err()
+}
+
+// setUp returns the position reached by playing the moves listed
+// after "moves" in the arguments of a position command.
+func setUp(variant *game.Variant, opening game.Opening, args []string) (*game.State, error) {
+	s := game.NewState(grid.New(variant.Size), game.Rules{Variant: variant, Opening: opening})
+	if len(args) == 0 {
+		return s, nil
+	}
+	if args[0] != "moves" {
+		return nil, fmt.Errorf("expected moves, found %q", args[0])
+	}
+
+	for _, arg := range args[1:] {
+		m, err := ParseMove(arg)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.Apply(m); err != nil {
+			return nil, fmt.Errorf("illegal move %s: %v", arg, err)
+		}
+	}
+	return s, nil
+}
+
+// moveTime returns the time given by the arguments of a go command,
+// or zero if none is.
+func moveTime(args []string) time.Duration {
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == "movetime" {
+			if ms, err := strconv.Atoi(args[i+1]); err == nil {
+				return time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	return 0
+}
+
+// search is a bot thinking about its next move in the background.
+type search struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// start has bot think about s for up to moveTime, if set, and send
+// the move it picks once it has.
+func start(bot ai.Bot, s *game.State, moveTime time.Duration, out *writer) *search {
+	ctx, cancel := context.WithCancel(context.Background())
+	deadline := time.Time{}
+	if moveTime > 0 {
+		deadline = time.Now().Add(moveTime)
+	}
+	search := &search{cancel: cancel, done: make(chan struct{})}
+
+	go func() {
+		defer close(search.done)
+		began := time.Now()
+		move, err := bot.Move(stoppable{Context: ctx, deadline: deadline}, s)
+		out.printf("info time %d", time.Since(began).Milliseconds())
+		if err != nil {
+			// the bot was stopped before it found a move, so any
+			// legal move is sent instead
+			legal := s.Legal()
+			if len(legal) == 0 {
+				out.printf("info string %v", err)
+				out.printf("bestmove none")
+				return
+			}
+			move = legal[0]
+		}
+		out.printf("bestmove %s", FormatMove(move))
+	}()
+	if moveTime > 0 {
+		time.AfterFunc(moveTime, cancel)
+	}
+	return search
+}
+
+// stop has the bot move straight away, unless it already has, and
+// waits until it has.
+func (s *search) stop() {
+	s.cancel()
+	<-s.done
+}
+
+// stoppable is the context of a search, which ends either at its
+// deadline or when stopped. Either way, it ends as if its deadline
+// had passed, so bots play the best move they found.
+type stoppable struct {
+	context.Context
+	deadline time.Time
+}
+
+func (s stoppable) Deadline() (time.Time, bool) {
+	return s.deadline, !s.deadline.IsZero()
+}
+
+func (s stoppable) Err() error {
+	if s.Context.Err() != nil {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+// writer writes whole lines to an output shared by the engine and
+// its search.
+type writer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (w *writer) printf(format string, args ...interface{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fmt.Fprintf(w.w, format+"\n", args...)
+}
@@ -0,0 +1,29 @@
+package engine
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/ai"
+)
+
+func TestServeGameOver(t *testing.T) {
+	in := strings.Join([]string{
+		"newgame standard",
+		"position moves 0,0 0,1 1,0 1,1 2,0",
+		"go movetime 10",
+		"quit",
+	}, "\n")
+	var out bytes.Buffer
+	err := Serve(strings.NewReader(in), &out, "test", func(seed int64) (ai.Bot, error) {
+		t.Fatal("a bot was asked to search a finished game")
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "bestmove none") {
+		t.Errorf("expected bestmove none, got %q", out.String())
+	}
+}
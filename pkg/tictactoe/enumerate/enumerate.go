@@ -0,0 +1,428 @@
+package enumerate
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/grid"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/shape"
+)
+
+// MaxSize is the size of the largest board whose positions can be
+// told apart by a key, which holds every cell as a base 3 digit.
+const MaxSize = 6
+
+const (
+	// shards is the number of parts the sets of positions seen are
+	// split into, each with its own lock.
+	shards = 64
+
+	// maxExamples bounds the number of mismatches kept as examples.
+	maxExamples = 10
+)
+
+// Config describes the game whose positions are enumerated.
+type Config struct {
+	// Size is the number of cells along each side of the board, and
+	// Length the number of shapes in a row needed to win.
+	Size   int
+	Length int
+	// Workers is the number of goroutines walking positions at once,
+	// or zero for one per CPU.
+	Workers int
+}
+
+// Outcome is how a game over ended.
+type Outcome int
+
+const (
+	CrossWin Outcome = iota
+	CircleWin
+	Draw
+	outcomes
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case CrossWin:
+		return "X wins"
+	case CircleWin:
+		return "O wins"
+	}
+	return "draw"
+}
+
+// Mismatch is a position whose result, as found by the rules of the
+// game, differs from the one found by looking for lines on its board.
+type Mismatch struct {
+	Position string
+	// Rules and Lines are the outcome found by each, or nil if the
+	// game goes on.
+	Rules *Outcome
+	Lines *Outcome
+}
+
+func (m Mismatch) String() string {
+	return fmt.Sprintf("position %s: the rules give %s, but its lines give %s", m.Position, describe(m.Rules), describe(m.Lines))
+}
+
+func describe(o *Outcome) string {
+	if o == nil {
+		return "a game going on"
+	}
+	return o.String()
+}
+
+// Stats counts the positions reachable from an empty board. Counts
+// are indexed by the number of shapes on the board. Unique counts
+// only count once the positions equal up to a rotation or reflection
+// of the board.
+type Stats struct {
+	Positions []int
+	Unique    []int
+	// Terminal and UniqueTerminal count the positions where the game
+	// is over, by how it ended.
+	Terminal       [outcomes]int
+	UniqueTerminal [outcomes]int
+	// Mismatches counts the positions the rules of the game and the
+	// lines on the board disagree about, and Examples holds some of
+	// them.
+	Mismatches int
+	Examples   []Mismatch
+}
+
+// Total returns the sum of counts.
+func Total(counts []int) int {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	return total
+}
+
+// Run walks every position reachable from an empty board, playing
+// moves with the rules of the game, and checks every one of them
+// for a win or a tie on its own. Every first move is walked from
+// by one of the workers, and positions reached by more than one
+// order of moves are walked from once.
+func Run(ctx context.Context, config Config) (*Stats, error) {
+	if config.Size < 1 || config.Size > MaxSize {
+		return nil, fmt.Errorf("boards of size %d cannot be enumerated: the size must be between 1 and %d", config.Size, MaxSize)
+	}
+	if config.Length < 1 || config.Length > config.Size {
+		return nil, fmt.Errorf("cannot need %d in a row on a board of size %d", config.Length, config.Size)
+	}
+	workers := config.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	e := newEnumeration(config)
+	root := e.walker()
+	root.visit(0, 0)
+	stats := root.stats
+
+	jobs := make(chan int)
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			walker := e.walker()
+			for i := range jobs {
+				if _, err := walker.state.Play(walker.state.Grid[i]); err != nil {
+					continue
+				}
+				walker.walk(ctx, e.pow3[i], 1)
+				walker.state.Undo()
+			}
+
+			mu.Lock()
+			stats.add(walker.stats)
+			mu.Unlock()
+		}()
+	}
+
+	for i := 0; i < config.Size*config.Size; i++ {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// enumeration holds what the workers of a run share: the positions
+// seen so far, as is and up to a symmetry of the board.
+type enumeration struct {
+	config     Config
+	variant    *game.Variant
+	pow3       []uint64
+	symmetries [8][]int
+	seen       *set
+	unique     *set
+}
+
+func newEnumeration(config Config) *enumeration {
+	e := &enumeration{
+		config: config,
+		variant: &game.Variant{
+			Name:      fmt.Sprintf("%dx%d", config.Size, config.Size),
+			Size:      config.Size,
+			WinLength: config.Length,
+		},
+		symmetries: grid.Symmetries(config.Size),
+		seen:       newSet(),
+		unique:     newSet(),
+	}
+	p := uint64(1)
+	for i := 0; i < config.Size*config.Size; i++ {
+		e.pow3 = append(e.pow3, p)
+		p *= 3
+	}
+	return e
+}
+
+// walker walks positions on its own copy of the game.
+type walker struct {
+	*enumeration
+	state *game.State
+	stats *Stats
+}
+
+func (e *enumeration) walker() *walker {
+	cells := e.config.Size * e.config.Size
+	return &walker{
+		enumeration: e,
+		state:       game.NewState(grid.New(e.config.Size), game.Rules{Variant: e.variant}),
+		stats:       &Stats{Positions: make([]int, cells+1), Unique: make([]int, cells+1)},
+	}
+}
+
+// walk counts the position of the walker, whose key is given,
+// reached after depth moves, and the positions reachable from it,
+// unless it was seen before. They are reached by playing every move
+// in turn and taking it back. The walk stops once ctx is done.
+func (w *walker) walk(ctx context.Context, key uint64, depth int) {
+	if depth <= 2 && ctx.Err() != nil {
+		return
+	}
+	if !w.visit(key, depth) {
+		return
+	}
+
+	s := w.state
+	for i, cell := range s.Grid {
+		if !cell.Empty() {
+			continue
+		}
+		kind := s.Turn()
+		if _, err := s.Play(cell); err != nil {
+			continue
+		}
+		w.walk(ctx, key+w.pow3[i]*digit(kind), depth+1)
+		s.Undo()
+	}
+}
+
+// visit counts the position of the walker, whose key is given,
+// reached after depth moves, unless it was seen before, and checks
+// the rules of the game agree with its lines. It returns true if
+// the position was not seen before and the game goes on.
+func (w *walker) visit(key uint64, depth int) bool {
+	if !w.seen.add(key) {
+		return false
+	}
+	s := w.state
+
+	w.stats.Positions[depth]++
+	unique := w.unique.add(w.canonical(s))
+	if unique {
+		w.stats.Unique[depth]++
+	}
+
+	rules, lines := w.outcome(s), w.lines(s)
+	if !same(rules, lines) {
+		w.stats.Mismatches++
+		if len(w.stats.Examples) < maxExamples {
+			w.stats.Examples = append(w.stats.Examples, Mismatch{Position: w.position(s), Rules: rules, Lines: lines})
+		}
+	}
+	if rules != nil {
+		w.stats.Terminal[*rules]++
+		if unique {
+			w.stats.UniqueTerminal[*rules]++
+		}
+		return false
+	}
+	return true
+}
+
+// outcome returns how the game in s ended by its rules, or nil if it
+// goes on.
+func (w *walker) outcome(s *game.State) *Outcome {
+	result := s.Result()
+	if result == nil {
+		return nil
+	}
+	o := Draw
+	switch result.Winner {
+	case shape.CrossShape:
+		o = CrossWin
+	case shape.CircleShape:
+		o = CircleWin
+	}
+	return &o
+}
+
+// lines returns how the game in s ended, found by looking for enough
+// shapes of a kind in a row on its board, or nil if it goes on.
+func (w *walker) lines(s *game.State) *Outcome {
+	size, length := w.config.Size, w.config.Length
+	at := func(x, y int) shape.ShapeKind {
+		if x < 0 || y < 0 || x >= size || y >= size {
+			return ""
+		}
+		return s.Grid[y*size+x].Kind()
+	}
+
+	full := true
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			kind := at(x, y)
+			if kind == "" {
+				full = false
+				continue
+			}
+			for _, d := range [][2]int{{1, 0}, {0, 1}, {1, 1}, {1, -1}} {
+				n := 1
+				for n < length && at(x+n*d[0], y+n*d[1]) == kind {
+					n++
+				}
+				if n == length {
+					o := CrossWin
+					if kind == shape.CircleShape {
+						o = CircleWin
+					}
+					return &o
+				}
+			}
+		}
+	}
+	if full {
+		o := Draw
+		return &o
+	}
+	return nil
+}
+
+func same(a, b *Outcome) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// canonical returns the smallest key of the position in s under the
+// symmetries of the board.
+func (w *walker) canonical(s *game.State) uint64 {
+	best := uint64(0)
+	for i, sym := range w.symmetries {
+		key := uint64(0)
+		for j, cell := range s.Grid {
+			key += w.pow3[sym[j]] * digit(cell.Kind())
+		}
+		if i == 0 || key < best {
+			best = key
+		}
+	}
+	return best
+}
+
+// position returns the position in s written row by row, with rows
+// separated by slashes.
+func (w *walker) position(s *game.State) string {
+	rows := []string{}
+	for y := 0; y < w.config.Size; y++ {
+		row := ""
+		for _, cell := range s.Grid[y*w.config.Size : (y+1)*w.config.Size] {
+			if cell.Empty() {
+				row += "."
+				continue
+			}
+			row += strings.ToLower(string(cell.Kind()))
+		}
+		rows = append(rows, row)
+	}
+	return strings.Join(rows, "/")
+}
+
+func digit(kind shape.ShapeKind) uint64 {
+	switch kind {
+	case shape.CrossShape:
+		return 1
+	case shape.CircleShape:
+		return 2
+	}
+	return 0
+}
+
+// add adds the counts of o to s.
+func (s *Stats) add(o *Stats) {
+	for i := range s.Positions {
+		s.Positions[i] += o.Positions[i]
+		s.Unique[i] += o.Unique[i]
+	}
+	for i := range s.Terminal {
+		s.Terminal[i] += o.Terminal[i]
+		s.UniqueTerminal[i] += o.UniqueTerminal[i]
+	}
+	s.Mismatches += o.Mismatches
+	for _, m := range o.Examples {
+		if len(s.Examples) < maxExamples {
+			s.Examples = append(s.Examples, m)
+		}
+	}
+}
+
+// set is a set of keys safe for concurrent use.
+type set struct {
+	shards [shards]struct {
+		mu   sync.Mutex
+		keys map[uint64]struct{}
+	}
+}
+
+func newSet() *set {
+	s := &set{}
+	for i := range s.shards {
+		s.shards[i].keys = make(map[uint64]struct{})
+	}
+	return s
+}
+
+// add adds key to the set, returning false if it was already in it.
+func (s *set) add(key uint64) bool {
+	shard := &s.shards[key%shards]
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if _, ok := shard.keys[key]; ok {
+		return false
+	}
+	shard.keys[key] = struct{}{}
+	return true
+}
@@ -0,0 +1,64 @@
+package enumerate
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name                     string
+		config                   Config
+		positions, unique        int
+		terminal, uniqueTerminal [outcomes]int
+	}{
+		{
+			name:           "3x3",
+			config:         Config{Size: 3, Length: 3},
+			positions:      5478,
+			unique:         765,
+			terminal:       [outcomes]int{CrossWin: 626, CircleWin: 316, Draw: 16},
+			uniqueTerminal: [outcomes]int{CrossWin: 91, CircleWin: 44, Draw: 3},
+		},
+		{
+			name:           "1x1",
+			config:         Config{Size: 1, Length: 1},
+			positions:      2,
+			unique:         2,
+			terminal:       [outcomes]int{CrossWin: 1},
+			uniqueTerminal: [outcomes]int{CrossWin: 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stats, err := Run(context.Background(), test.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := Total(stats.Positions); got != test.positions {
+				t.Errorf("expected %d positions, got %d", test.positions, got)
+			}
+			if got := Total(stats.Unique); got != test.unique {
+				t.Errorf("expected %d unique positions, got %d", test.unique, got)
+			}
+			if stats.Terminal != test.terminal {
+				t.Errorf("expected terminal positions %v, got %v", test.terminal, stats.Terminal)
+			}
+			if stats.UniqueTerminal != test.uniqueTerminal {
+				t.Errorf("expected unique terminal positions %v, got %v", test.uniqueTerminal, stats.UniqueTerminal)
+			}
+			if stats.Mismatches != 0 {
+				t.Errorf("expected no mismatches, got %d: %v", stats.Mismatches, stats.Examples)
+			}
+		})
+	}
+}
+
+func TestRunInvalid(t *testing.T) {
+	for _, config := range []Config{{Size: 0, Length: 1}, {Size: MaxSize + 1, Length: 3}, {Size: 3, Length: 4}} {
+		if _, err := Run(context.Background(), config); err == nil {
+			t.Errorf("%+v: expected an error", config)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Handicap evens out a game between players of different strength
+// by favoring the weaker side.
+type Handicap struct {
+	// Weak is the index of the side receiving the handicap.
+	Weak int `json:"weak"`
+	// Stones is the number of extra stones the weak side places
+	// before the game begins.
+	Stones int `json:"stones,omitempty"`
+	// Center pre-places a stone of the weak side on the center cell.
+	Center bool `json:"center,omitempty"`
+	// StrongLength and WeakLength are the run lengths each side needs
+	// to win, or zero to use the length of the variant.
+	StrongLength int `json:"strongLength,omitempty"`
+	WeakLength   int `json:"weakLength,omitempty"`
+}
+
+// ParseHandicap parses a comma-separated list of handicap settings,
+// e.g. "weak=2,stones=1,center,strong-length=4". The weak player is
+// numbered from 1. An empty string means no handicap.
+func ParseHandicap(handicap string) (Handicap, error) {
+	h := Handicap{}
+	if handicap == "" {
+		return h, nil
+	}
+
+	for _, setting := range strings.Split(handicap, ",") {
+		key, value, _ := strings.Cut(setting, "=")
+		if key == "center" {
+			h.Center = true
+			continue
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return Handicap{}, fmt.Errorf("invalid handicap setting %q: expected a positive number", setting)
+		}
+
+		switch key {
+		case "weak":
+			if n != 1 && n != 2 {
+				return Handicap{}, fmt.Errorf("invalid handicap setting %q: weak player must be 1 or 2", setting)
+			}
+			h.Weak = n - 1
+		case "stones":
+			h.Stones = n
+		case "strong-length":
+			h.StrongLength = n
+		case "weak-length":
+			h.WeakLength = n
+		default:
+			return Handicap{}, fmt.Errorf("unknown handicap setting %q", setting)
+		}
+	}
+	return h, nil
+}
+
+// Describe returns a short summary of the handicap as it applies
+// to the side at index i, or an empty string if it does not.
+func (h Handicap) Describe(i int) string {
+	parts := []string{}
+	if i == h.Weak {
+		if h.Stones > 0 {
+			parts = append(parts, fmt.Sprintf("+%d", h.Stones))
+		}
+		if h.Center {
+			parts = append(parts, "C")
+		}
+		if h.WeakLength > 0 {
+			parts = append(parts, fmt.Sprintf("K%d", h.WeakLength))
+		}
+	} else if h.StrongLength > 0 {
+		parts = append(parts, fmt.Sprintf("K%d", h.StrongLength))
+	}
+	return strings.Join(parts, " ")
+}
+
+// length returns the run length the side at index i needs to win,
+// or def if the handicap does not change it.
+func (h Handicap) length(i, def int) int {
+	switch {
+	case i == h.Weak && h.WeakLength > 0:
+		return h.WeakLength
+	case i != h.Weak && h.StrongLength > 0:
+		return h.StrongLength
+	}
+	return def
+}
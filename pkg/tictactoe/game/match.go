@@ -0,0 +1,137 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MatchFormat describes how many games are played in a match and
+// how many of them must be won to take it.
+type MatchFormat struct {
+	// Games is the most games the match may last, or zero if the
+	// match goes on until a side reaches Target wins.
+	Games int
+	// Target is the number of wins that takes the match.
+	Target int
+}
+
+var SingleGame = MatchFormat{Games: 1, Target: 1}
+
+func BestOf(games int) MatchFormat {
+	return MatchFormat{Games: games, Target: games/2 + 1}
+}
+
+func FirstTo(wins int) MatchFormat {
+	return MatchFormat{Target: wins}
+}
+
+// ParseMatchFormat parses "single", "bo<N>" (best of N games, with
+// N odd) or "first-to-<N>".
+func ParseMatchFormat(format string) (MatchFormat, error) {
+	switch {
+	case format == "single":
+		return SingleGame, nil
+	case strings.HasPrefix(format, "bo"):
+		n, err := strconv.Atoi(strings.TrimPrefix(format, "bo"))
+		if err != nil || n < 1 || n%2 == 0 {
+			return MatchFormat{}, fmt.Errorf("invalid match format %q: best-of matches need an odd number of games", format)
+		}
+		return BestOf(n), nil
+	case strings.HasPrefix(format, "first-to-"):
+		n, err := strconv.Atoi(strings.TrimPrefix(format, "first-to-"))
+		if err != nil || n < 1 {
+			return MatchFormat{}, fmt.Errorf("invalid match format %q: expected a positive number of wins", format)
+		}
+		return FirstTo(n), nil
+	}
+
+	return MatchFormat{}, fmt.Errorf("unknown match format %q", format)
+}
+
+func (f MatchFormat) String() string {
+	switch {
+	case f == SingleGame:
+		return "SINGLE GAME"
+	case f.Games == 0:
+		return fmt.Sprintf("FIRST TO %d", f.Target)
+	}
+	return fmt.Sprintf("BEST OF %d", f.Games)
+}
+
+// Match is a series of games between the two sides of a state,
+// alternating which side starts each game.
+type Match struct {
+	Format MatchFormat
+
+	wins  []int
+	games int
+}
+
+// Record counts the result of the finished game in s.
+func (m *Match) Record(s *State) {
+	result := s.Result()
+	if result == nil || m.Over() {
+		return
+	}
+
+	m.games++
+	if result.Winner != "" {
+		m.wins[s.indexOf(s.SideOf(result.Winner))]++
+	}
+}
+
+// Wins returns the number of games won by the side at index i.
+func (m *Match) Wins(i int) int {
+	return m.wins[i]
+}
+
+// Games returns the number of games played so far.
+func (m *Match) Games() int {
+	return m.games
+}
+
+// Starter returns the index of the side starting the next game.
+func (m *Match) Starter() int {
+	return m.games % len(m.wins)
+}
+
+func (m *Match) Over() bool {
+	for _, w := range m.wins {
+		if w >= m.Format.Target {
+			return true
+		}
+	}
+	return m.Format.Games > 0 && m.games >= m.Format.Games
+}
+
+// Winner returns the index of the side that won the match, or -1
+// if the match is still being played or ended in a tie.
+func (m *Match) Winner() int {
+	if !m.Over() {
+		return -1
+	}
+
+	winner := -1
+	for i, w := range m.wins {
+		if winner < 0 || w > m.wins[winner] {
+			winner = i
+		} else if w == m.wins[winner] {
+			winner = -1
+		}
+	}
+	return winner
+}
+
+// Reset starts the match over.
+func (m *Match) Reset() {
+	m.wins = make([]int, len(m.wins))
+	m.games = 0
+}
+
+func NewMatch(format MatchFormat) *Match {
+	return &Match{
+		Format: format,
+		wins:   make([]int, 2),
+	}
+}
@@ -0,0 +1,205 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/shape"
+)
+
+var (
+	ErrChoicePending = errors.New("a side must be chosen before playing on")
+	ErrInvalidChoice = errors.New("choice is not available")
+)
+
+// Opening is a protocol played before a game proper begins, to
+// offset the advantage of moving first.
+type Opening string
+
+const (
+	NoOpening Opening = "none"
+	// Pie lets the second player swap sides after the first move.
+	Pie Opening = "pie"
+	// Swap2 has the first player place three stones, after which the
+	// second player picks a side or places two more stones and lets
+	// the first player pick instead.
+	Swap2 Opening = "swap2"
+)
+
+var Openings = []Opening{NoOpening, Pie, Swap2}
+
+func OpeningByName(name string) (Opening, error) {
+	for _, o := range Openings {
+		if string(o) == name {
+			return o, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown opening %q", name)
+}
+
+// Choice is a decision made by a side during an opening.
+type Choice string
+
+const (
+	// ChoiceSwap exchanges shapes between the two sides.
+	ChoiceSwap Choice = "swap"
+	// ChoiceKeep keeps the shapes each side currently has.
+	ChoiceKeep Choice = "keep"
+	// ChoicePlaceTwo places two more stones and passes the choice
+	// of side back to the opponent.
+	ChoicePlaceTwo Choice = "place two"
+)
+
+type Phase int
+
+const (
+	// PhaseOpening is when one side places opening stones for
+	// both shapes.
+	PhaseOpening Phase = iota
+	// PhaseChoice is when one side must make a choice.
+	PhaseChoice
+	PhasePlay
+	PhaseOver
+)
+
+// Side is a player, or a team of players taking turns, and the
+// kind of shape they currently place.
+type Side struct {
+	Name    string
+	Kind    shape.ShapeKind
+	Members []string
+
+	turns int
+}
+
+// Player returns the member of the side due to make its next move.
+func (s *Side) Player() string {
+	return s.Members[s.turns%len(s.Members)]
+}
+
+func (s *State) Phase() Phase {
+	switch {
+	case s.result != nil:
+		return PhaseOver
+	case len(s.choices) > 0:
+		return PhaseChoice
+	case s.bonus > 0 || s.opening > 0:
+		return PhaseOpening
+	}
+	return PhasePlay
+}
+
+// Sides returns both sides, starting with the first player.
+func (s *State) Sides() []*Side {
+	return s.sides
+}
+
+// SideOf returns the side placing the given kind of shape.
+func (s *State) SideOf(kind shape.ShapeKind) *Side {
+	for _, side := range s.sides {
+		if side.Kind == kind {
+			return side
+		}
+	}
+	return nil
+}
+
+// Current returns the side expected to act next: placing an
+// opening stone, making a choice, or playing a regular move.
+func (s *State) Current() *Side {
+	if s.bonus > 0 {
+		return s.sides[s.Rules.Handicap.Weak]
+	}
+
+	switch s.Phase() {
+	case PhaseOpening, PhaseChoice:
+		return s.sides[s.actor]
+	}
+	return s.SideOf(s.Turn())
+}
+
+// OpeningStones returns the number of opening or handicap stones
+// the current side has left to place.
+func (s *State) OpeningStones() int {
+	if s.bonus > 0 {
+		return s.bonus
+	}
+	return s.opening
+}
+
+// Choices returns the choices available to the current side, or
+// nil if no choice is pending.
+func (s *State) Choices() []Choice {
+	return s.choices
+}
+
+// Choose applies a choice made by the current side.
+func (s *State) Choose(choice Choice) error {
+	if s.Phase() != PhaseChoice {
+		return ErrInvalidChoice
+	}
+
+	valid := false
+	for _, c := range s.choices {
+		valid = valid || c == choice
+	}
+	if !valid {
+		return fmt.Errorf("%w: %s", ErrInvalidChoice, choice)
+	}
+
+	side := s.Current()
+	s.history = append(s.history, Entry{Move: Move{Choice: choice}, Side: side.Name, Player: side.Player(), undo: s.snapshot()})
+	s.choices = nil
+	switch choice {
+	case ChoiceSwap:
+		s.sides[0].Kind, s.sides[1].Kind = s.sides[1].Kind, s.sides[0].Kind
+	case ChoicePlaceTwo:
+		s.opening = 2
+	}
+	return nil
+}
+
+// beginOpening starts the opening protocol for a new game.
+func (s *State) beginOpening() {
+	s.choices = nil
+	s.opening = 0
+	s.actor = s.indexOf(s.SideOf(s.first))
+
+	switch s.Rules.Opening {
+	case Pie:
+		s.opening = 1
+	case Swap2:
+		s.opening = 3
+	}
+}
+
+// advanceOpening moves the opening protocol along after an
+// opening stone has been placed.
+func (s *State) advanceOpening() {
+	if s.opening == 0 {
+		return
+	}
+
+	s.opening--
+	if s.opening > 0 {
+		return
+	}
+
+	// the choice falls to the side that did not place the
+	// last opening stones
+	s.actor = 1 - s.actor
+	s.choices = []Choice{ChoiceSwap, ChoiceKeep}
+	if s.Rules.Opening == Swap2 && s.moves == 3 {
+		s.choices = append(s.choices, ChoicePlaceTwo)
+	}
+}
+
+func (s *State) indexOf(side *Side) int {
+	for i := range s.sides {
+		if s.sides[i] == side {
+			return i
+		}
+	}
+	return 0
+}
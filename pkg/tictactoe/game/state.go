@@ -0,0 +1,401 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/grid"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/shape"
+)
+
+var (
+	ErrOccupied  = errors.New("cell is already occupied")
+	ErrGameOver  = errors.New("game is over")
+	ErrForbidden = errors.New("move is forbidden")
+	ErrNoUndo    = errors.New("no move to undo")
+)
+
+// Rules configures how every game of a state is played.
+type Rules struct {
+	Variant  *Variant
+	Opening  Opening
+	Handicap Handicap
+	// Teams has two teams of two players share a shape each, with
+	// team members taking turns to move for their team.
+	Teams bool
+}
+
+// Result describes how a game ended. A tie has an empty Winner.
+type Result struct {
+	Winner shape.ShapeKind
+	// Run is the winning line, or nil if the game was not won
+	// by getting enough shapes in a row.
+	Run *grid.Run
+}
+
+// Move is either a shape placed on the cell at X, Y or, if Choice
+// is set, a choice made during an opening.
+type Move struct {
+	X      int
+	Y      int
+	Choice Choice
+}
+
+// Entry is a move in the history of a game, along with the side
+// and player that made it and the kind of shape it placed.
+type Entry struct {
+	Move
+	Side   string
+	Player string
+	Kind   shape.ShapeKind
+
+	// undo holds what is needed to take the move back, or nil
+	// for stones placed while setting up the game.
+	undo *snapshot
+}
+
+// snapshot is the part of a state changed by a move, other than
+// the cell it was played on.
+type snapshot struct {
+	next     shape.ShapeKind
+	kinds    []shape.ShapeKind
+	turns    []int
+	moves    int
+	bonus    int
+	opening  int
+	actor    int
+	choices  []Choice
+	captured []*grid.Cell
+}
+
+// State tracks a single game played on a grid under a set of rules.
+type State struct {
+	Grid  grid.Grid
+	Rules Rules
+
+	sides    []*Side
+	decider  *shape.ShapeDecider
+	first    shape.ShapeKind
+	moves    int
+	captures map[shape.ShapeKind]int
+	history  []Entry
+	result   *Result
+
+	// bonus counts the handicap stones the weak side has left to
+	// place before the game begins.
+	bonus int
+
+	// While the opening protocol runs, opening counts the opening
+	// stones left for the side at actor to place, and choices holds
+	// any choice that side must make.
+	opening int
+	actor   int
+	choices []Choice
+
+	// forbidden caches the cells the player to move may not play
+	// on, until the next move is made.
+	forbidden []*grid.Cell
+}
+
+// Turn returns the kind of shape that will be placed next.
+func (s *State) Turn() shape.ShapeKind {
+	if s.bonus > 0 {
+		return s.sides[s.Rules.Handicap.Weak].Kind
+	}
+	return s.decider.Peek()
+}
+
+// First returns the kind of shape that opened the game.
+func (s *State) First() shape.ShapeKind {
+	return s.first
+}
+
+// Captures returns the number of pairs captured by kind.
+func (s *State) Captures(kind shape.ShapeKind) int {
+	return s.captures[kind]
+}
+
+// History returns every move made so far in the game.
+func (s *State) History() []Entry {
+	return s.history
+}
+
+// Result returns the outcome of the game, or nil if the
+// game is still in progress.
+func (s *State) Result() *Result {
+	return s.result
+}
+
+// WinLength returns the run length kind needs to win the game.
+func (s *State) WinLength(kind shape.ShapeKind) int {
+	return s.Rules.Handicap.length(s.indexOf(s.SideOf(kind)), s.Rules.Variant.WinLength)
+}
+
+// Restricted returns true if kind is bound by the Renju rules.
+func (s *State) Restricted(kind shape.ShapeKind) bool {
+	return s.Rules.Variant.Renju && kind == s.first
+}
+
+// Forbidden returns the empty cells the player to move is not
+// allowed to play on.
+func (s *State) Forbidden() []*grid.Cell {
+	if s.forbidden != nil {
+		return s.forbidden
+	}
+
+	s.forbidden = []*grid.Cell{}
+	if s.result != nil || !s.Restricted(s.Turn()) {
+		return s.forbidden
+	}
+	for _, cell := range s.Grid {
+		if cell.Empty() && s.Grid.Forbidden(cell, s.Turn()) != grid.Unrestricted {
+			s.forbidden = append(s.forbidden, cell)
+		}
+	}
+	return s.forbidden
+}
+
+// Validate returns an error if the next shape may not be
+// placed on cell.
+func (s *State) Validate(cell *grid.Cell) error {
+	if s.result != nil {
+		return ErrGameOver
+	}
+	if s.Phase() == PhaseChoice {
+		return ErrChoicePending
+	}
+	if !cell.Empty() {
+		return ErrOccupied
+	}
+	if s.Restricted(s.Turn()) {
+		if restriction := s.Grid.Forbidden(cell, s.Turn()); restriction != grid.Unrestricted {
+			return fmt.Errorf("%w: %s", ErrForbidden, restriction)
+		}
+	}
+	return nil
+}
+
+// Play places the next shape on cell, removing any pairs it
+// captures, and checks whether the move ended the game.
+// The returned cells are the ones whose shapes were captured.
+func (s *State) Play(cell *grid.Cell) ([]*grid.Cell, error) {
+	if err := s.Validate(cell); err != nil {
+		return nil, err
+	}
+
+	side := s.Current()
+	bonus := s.bonus > 0
+	kind := s.Turn()
+	undo := s.snapshot()
+	if bonus {
+		s.bonus--
+	} else {
+		s.decider.Next()
+	}
+
+	cell.Place(kind)
+	s.moves++
+	s.forbidden = nil
+	s.history = append(s.history, Entry{Move: Move{X: cell.X(), Y: cell.Y()}, Side: side.Name, Player: side.Player(), Kind: kind, undo: undo})
+	side.turns++
+
+	captured := []*grid.Cell{}
+	if s.Rules.Variant.Captures {
+		captured = s.Grid.Capture(cell)
+		for _, c := range captured {
+			c.Remove()
+		}
+		s.captures[kind] += len(captured) / 2
+		undo.captured = captured
+	}
+
+	if run := s.Grid.RunThrough(cell, s.WinLength(kind), s.Restricted(kind)); run != nil {
+		s.result = &Result{Winner: kind, Run: run}
+	} else if s.Rules.Variant.CaptureWin > 0 && s.captures[kind] >= s.Rules.Variant.CaptureWin {
+		s.result = &Result{Winner: kind}
+	} else if s.Grid.Full() {
+		s.result = &Result{}
+	} else if !bonus {
+		s.advanceOpening()
+	}
+
+	return captured, nil
+}
+
+// Apply plays a move, either placing a shape or making a choice.
+func (s *State) Apply(m Move) error {
+	if m.Choice != "" {
+		return s.Choose(m.Choice)
+	}
+
+	cell := s.Grid.At(m.X, m.Y)
+	if cell == nil {
+		return fmt.Errorf("cell %d,%d is outside of the grid", m.X, m.Y)
+	}
+	_, err := s.Play(cell)
+	return err
+}
+
+// Legal returns every move the current side may make.
+func (s *State) Legal() []Move {
+	moves := []Move{}
+	switch s.Phase() {
+	case PhaseOver:
+		return moves
+	case PhaseChoice:
+		for _, c := range s.choices {
+			moves = append(moves, Move{Choice: c})
+		}
+		return moves
+	}
+
+	restricted := s.Restricted(s.Turn())
+	for _, cell := range s.Grid {
+		if !cell.Empty() || (restricted && s.Grid.Forbidden(cell, s.Turn()) != grid.Unrestricted) {
+			continue
+		}
+		moves = append(moves, Move{X: cell.X(), Y: cell.Y()})
+	}
+	return moves
+}
+
+// Undo takes back the last move made in the game.
+func (s *State) Undo() error {
+	if len(s.history) == 0 || s.history[len(s.history)-1].undo == nil {
+		return ErrNoUndo
+	}
+
+	entry := s.history[len(s.history)-1]
+	s.history = s.history[:len(s.history)-1]
+	undo := entry.undo
+
+	if entry.Choice == "" {
+		s.Grid.At(entry.X, entry.Y).Remove()
+		for _, c := range undo.captured {
+			c.Place(s.other(entry.Kind))
+		}
+		s.captures[entry.Kind] -= len(undo.captured) / 2
+	}
+
+	s.decider = shape.NewShapeDecider(undo.next)
+	for i, side := range s.sides {
+		side.Kind = undo.kinds[i]
+		side.turns = undo.turns[i]
+	}
+	s.moves = undo.moves
+	s.bonus = undo.bonus
+	s.opening = undo.opening
+	s.actor = undo.actor
+	s.choices = undo.choices
+	s.result = nil
+	s.forbidden = nil
+	return nil
+}
+
+// Clone returns a copy of the state, and of its grid, that can be
+// played on without affecting the original.
+func (s *State) Clone() *State {
+	clone := *s
+	clone.Grid = s.Grid.Clone()
+	clone.decider = shape.NewShapeDecider(s.decider.Peek())
+	clone.captures = make(map[shape.ShapeKind]int)
+	for k, v := range s.captures {
+		clone.captures[k] = v
+	}
+	clone.sides = []*Side{}
+	for _, side := range s.sides {
+		copied := *side
+		clone.sides = append(clone.sides, &copied)
+	}
+	clone.history = make([]Entry, len(s.history))
+	for i, entry := range s.history {
+		clone.history[i] = entry
+		if entry.undo != nil {
+			undo := *entry.undo
+			undo.captured = nil
+			for _, c := range entry.undo.captured {
+				undo.captured = append(undo.captured, clone.Grid.At(c.X(), c.Y()))
+			}
+			clone.history[i].undo = &undo
+		}
+	}
+	clone.forbidden = nil
+	return &clone
+}
+
+func (s *State) snapshot() *snapshot {
+	snap := &snapshot{
+		next:    s.decider.Peek(),
+		moves:   s.moves,
+		bonus:   s.bonus,
+		opening: s.opening,
+		actor:   s.actor,
+		choices: s.choices,
+	}
+	for _, side := range s.sides {
+		snap.kinds = append(snap.kinds, side.Kind)
+		snap.turns = append(snap.turns, side.turns)
+	}
+	return snap
+}
+
+// other returns the kind of shape played by the opponent of kind.
+func (s *State) other(kind shape.ShapeKind) shape.ShapeKind {
+	if kind == shape.CrossShape {
+		return shape.CircleShape
+	}
+	return shape.CrossShape
+}
+
+// Reset clears the grid and any game progress, and starts a new
+// game opened by the side at index starter.
+func (s *State) Reset(starter int) {
+	s.Grid.Reset()
+	s.sides[starter].Kind = shape.CrossShape
+	s.sides[1-starter].Kind = shape.CircleShape
+	for _, side := range s.sides {
+		side.turns = 0
+	}
+	s.decider = shape.NewShapeDecider(shape.CrossShape)
+	s.captures = make(map[shape.ShapeKind]int)
+	s.history = nil
+	s.result = nil
+	s.first = s.Turn()
+	s.moves = 0
+	s.forbidden = nil
+	s.beginHandicap()
+	s.beginOpening()
+}
+
+// beginHandicap sets up the handicap stones of the weak side for
+// a new game.
+func (s *State) beginHandicap() {
+	weak := s.sides[s.Rules.Handicap.Weak]
+	s.bonus = s.Rules.Handicap.Stones
+
+	if s.Rules.Handicap.Center {
+		center := s.Grid.At(s.Grid.Size()/2, s.Grid.Size()/2)
+		center.Place(weak.Kind)
+		s.history = append(s.history, Entry{Move: Move{X: center.X(), Y: center.Y()}, Side: weak.Name, Player: weak.Player(), Kind: weak.Kind})
+	}
+}
+
+func NewState(g grid.Grid, rules Rules) *State {
+	s := &State{
+		Grid:  g,
+		Rules: rules,
+		sides: []*Side{
+			{Name: "PLAYER 1", Members: []string{"PLAYER 1"}},
+			{Name: "PLAYER 2", Members: []string{"PLAYER 2"}},
+		},
+	}
+	if rules.Teams {
+		s.sides = []*Side{
+			{Name: "TEAM A", Members: []string{"A1", "A2"}},
+			{Name: "TEAM B", Members: []string{"B1", "B2"}},
+		}
+	}
+
+	s.Reset(0)
+	return s
+}
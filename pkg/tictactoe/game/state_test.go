@@ -0,0 +1,181 @@
+package game
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/grid"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/shape"
+)
+
+// play returns a new game of variant with moves played in turn,
+// starting with X.
+func play(t *testing.T, variant *Variant, moves ...Move) *State {
+	t.Helper()
+	s := NewState(grid.New(variant.Size), Rules{Variant: variant})
+	for _, m := range moves {
+		if err := s.Apply(m); err != nil {
+			t.Fatalf("move %v: %v", m, err)
+		}
+	}
+	return s
+}
+
+func TestPenteCaptures(t *testing.T) {
+	tests := []struct {
+		name     string
+		moves    []Move
+		captures int
+		// empty lists the cells left empty by the captures.
+		empty []Move
+	}{
+		{
+			name:     "pair",
+			moves:    []Move{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 10, Y: 10}, {X: 2, Y: 0}, {X: 3, Y: 0}},
+			captures: 1,
+			empty:    []Move{{X: 1, Y: 0}, {X: 2, Y: 0}},
+		},
+		{
+			name:     "two pairs at once",
+			moves:    []Move{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 3, Y: 3}, {X: 2, Y: 0}, {X: 10, Y: 10}, {X: 3, Y: 1}, {X: 12, Y: 12}, {X: 3, Y: 2}, {X: 3, Y: 0}},
+			captures: 2,
+			empty:    []Move{{X: 1, Y: 0}, {X: 2, Y: 0}, {X: 3, Y: 1}, {X: 3, Y: 2}},
+		},
+		{
+			name:  "diagonal pair",
+			moves: []Move{{X: 5, Y: 5}, {X: 6, Y: 6}, {X: 10, Y: 10}, {X: 7, Y: 7}, {X: 8, Y: 8}},
+			// the pair is captured by X, which moved first
+			captures: 1,
+			empty:    []Move{{X: 6, Y: 6}, {X: 7, Y: 7}},
+		},
+		{
+			name:  "moving into a flanked pair",
+			moves: []Move{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 3, Y: 0}, {X: 2, Y: 0}},
+		},
+		{
+			name:  "single stone",
+			moves: []Move{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 2, Y: 0}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := play(t, Pente, test.moves...)
+			if got := s.Captures(shape.CrossShape) + s.Captures(shape.CircleShape); got != test.captures {
+				t.Errorf("expected %d pairs captured, got %d", test.captures, got)
+			}
+			for _, m := range test.empty {
+				if !s.Grid.At(m.X, m.Y).Empty() {
+					t.Errorf("expected %d,%d to be captured", m.X, m.Y)
+				}
+			}
+
+			if err := s.Undo(); err != nil {
+				t.Fatal(err)
+			}
+			for _, m := range test.empty {
+				if s.Grid.At(m.X, m.Y).Empty() {
+					t.Errorf("expected %d,%d to be restored by the undo", m.X, m.Y)
+				}
+			}
+			if got := s.Captures(shape.CrossShape) + s.Captures(shape.CircleShape); got != 0 {
+				t.Errorf("expected no pairs captured after the undo, got %d", got)
+			}
+		})
+	}
+}
+
+func TestPenteCaptureWin(t *testing.T) {
+	s := play(t, Pente)
+	for i := 0; i < Pente.CaptureWin; i++ {
+		y := 2 * i
+		for _, m := range []Move{{X: 0, Y: y}, {X: 1, Y: y}, {X: 18, Y: y}, {X: 2, Y: y}, {X: 3, Y: y}, {X: 17, Y: y}} {
+			if s.Result() != nil {
+				break
+			}
+			if err := s.Apply(m); err != nil {
+				t.Fatalf("move %v: %v", m, err)
+			}
+		}
+	}
+
+	result := s.Result()
+	if result == nil || result.Winner != shape.CrossShape || result.Run != nil {
+		t.Fatalf("expected X to win by captures, got %+v", result)
+	}
+	if got := s.Captures(shape.CrossShape); got != Pente.CaptureWin {
+		t.Errorf("expected %d pairs captured, got %d", Pente.CaptureWin, got)
+	}
+}
+
+func TestRenjuRestrictions(t *testing.T) {
+	// filler moves of the side not being tested, far from the shapes
+	filler := func(y int) Move { return Move{X: 0, Y: y} }
+	tests := []struct {
+		name        string
+		moves       []Move
+		move        Move
+		restriction grid.Restriction
+		wins        bool
+	}{
+		{
+			name:        "double three",
+			moves:       []Move{{X: 7, Y: 5}, filler(0), {X: 7, Y: 6}, filler(2), {X: 5, Y: 7}, filler(4), {X: 6, Y: 7}, filler(6)},
+			move:        Move{X: 7, Y: 7},
+			restriction: grid.DoubleThree,
+		},
+		{
+			name:        "double four",
+			moves:       []Move{{X: 7, Y: 3}, filler(0), {X: 7, Y: 4}, filler(2), {X: 7, Y: 5}, filler(4), {X: 3, Y: 7}, filler(6), {X: 4, Y: 7}, filler(8), {X: 5, Y: 7}, filler(10)},
+			move:        Move{X: 7, Y: 7},
+			restriction: grid.DoubleFour,
+		},
+		{
+			name:        "overline",
+			moves:       []Move{{X: 2, Y: 7}, filler(0), {X: 3, Y: 7}, filler(2), {X: 4, Y: 7}, filler(4), {X: 6, Y: 7}, filler(6), {X: 7, Y: 7}, filler(8)},
+			move:        Move{X: 5, Y: 7},
+			restriction: grid.Overline,
+		},
+		{
+			name:  "exactly five",
+			moves: []Move{{X: 3, Y: 7}, filler(0), {X: 4, Y: 7}, filler(2), {X: 5, Y: 7}, filler(4), {X: 6, Y: 7}, filler(6)},
+			move:  Move{X: 7, Y: 7},
+			wins:  true,
+		},
+		{
+			name:  "double three by the second player",
+			moves: []Move{{X: 14, Y: 0}, {X: 7, Y: 5}, {X: 14, Y: 2}, {X: 7, Y: 6}, {X: 14, Y: 4}, {X: 5, Y: 7}, {X: 14, Y: 6}, {X: 6, Y: 7}, {X: 14, Y: 8}},
+			move:  Move{X: 7, Y: 7},
+		},
+		{
+			name:  "overline by the second player",
+			moves: []Move{{X: 14, Y: 0}, {X: 2, Y: 7}, {X: 14, Y: 2}, {X: 3, Y: 7}, {X: 14, Y: 4}, {X: 4, Y: 7}, {X: 14, Y: 6}, {X: 6, Y: 7}, {X: 14, Y: 8}, {X: 7, Y: 7}, {X: 14, Y: 10}},
+			move:  Move{X: 5, Y: 7},
+			wins:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := play(t, Renju, test.moves...)
+			kind := s.Turn()
+			cell := s.Grid.At(test.move.X, test.move.Y)
+			if test.restriction != grid.Unrestricted {
+				if got := s.Grid.Forbidden(cell, kind); got != test.restriction {
+					t.Errorf("expected a %s, got %q", test.restriction, got)
+				}
+				if err := s.Apply(test.move); !errors.Is(err, ErrForbidden) {
+					t.Errorf("expected the move to be rejected, got %v", err)
+				}
+				return
+			}
+
+			if err := s.Apply(test.move); err != nil {
+				t.Fatal(err)
+			}
+			if won := s.Result() != nil && s.Result().Winner == kind; won != test.wins {
+				t.Errorf("expected the move to win: %v, got %v", test.wins, won)
+			}
+		})
+	}
+}
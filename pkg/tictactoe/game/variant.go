@@ -0,0 +1,65 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/grid"
+)
+
+// Variant describes the board and winning conditions of a game.
+type Variant struct {
+	Name string
+
+	// Size is the number of cells along each side of the board.
+	Size int
+	// WinLength is the number of shapes in a row needed to win.
+	WinLength int
+
+	// Captures enables Pente-style pair captures.
+	Captures bool
+	// CaptureWin is the number of captured pairs needed to win,
+	// or zero if captures never decide a game.
+	CaptureWin int
+
+	// Renju restricts the first player: double-threes, double-fours
+	// and overlines are forbidden, and exactly five is needed to win.
+	Renju bool
+}
+
+var (
+	Standard = &Variant{
+		Name:      "standard",
+		Size:      grid.MaxCells,
+		WinLength: grid.MaxCells,
+	}
+	Pente = &Variant{
+		Name:       "pente",
+		Size:       19,
+		WinLength:  5,
+		Captures:   true,
+		CaptureWin: 5,
+	}
+	Gomoku = &Variant{
+		Name:      "gomoku",
+		Size:      15,
+		WinLength: 5,
+	}
+	Renju = &Variant{
+		Name:      "renju",
+		Size:      15,
+		WinLength: 5,
+		Renju:     true,
+	}
+
+	Variants = []*Variant{Standard, Pente, Gomoku, Renju}
+)
+
+func VariantByName(name string) (*Variant, error) {
+	for _, v := range Variants {
+		if v.Name == name {
+			return v, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown variant %q", name)
+}
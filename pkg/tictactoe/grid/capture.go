@@ -0,0 +1,33 @@
+package grid
+
+// Capture returns every pair of opponent shapes sandwiched
+// between cell and another shape of the same kind as cell,
+// along any of the eight directions. Runs of one, or of more
+// than two, opponent shapes are never captured.
+func (g Grid) Capture(cell *Cell) []*Cell {
+	captured := []*Cell{}
+	if cell == nil || cell.value == nil {
+		return captured
+	}
+
+	for _, dir := range Directions {
+		first := cell.Neighbor(dir)
+		if first == nil || first.value == nil || first.Kind() == cell.Kind() {
+			continue
+		}
+
+		second := first.Neighbor(dir)
+		if second == nil || second.Kind() != first.Kind() {
+			continue
+		}
+
+		closing := second.Neighbor(dir)
+		if closing == nil || closing.Kind() != cell.Kind() {
+			continue
+		}
+
+		captured = append(captured, first, second)
+	}
+
+	return captured
+}
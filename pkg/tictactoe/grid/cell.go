@@ -2,6 +2,7 @@ package grid
 
 import (
 	"image/color"
+	"math"
 
 	"github.com/faiface/pixel"
 	"github.com/faiface/pixel/imdraw"
@@ -9,13 +10,52 @@ import (
 	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/shape"
 )
 
+const (
+	// shapeMarginRatio is the fraction of a cell's shortest side
+	// left empty around a shape placed in it.
+	shapeMarginRatio = 0.15
+
+	// markRatio is the fraction of a cell's shortest side covered
+	// by a mark rendered in it.
+	markRatio = 0.3
+
+	// fadeStep is the opacity lost by a removed shape on each
+	// rendered frame.
+	fadeStep = 1.0 / 30
+)
+
+type Direction int
+
+const (
+	TopLeft Direction = iota
+	Top
+	TopRight
+	Left
+	Right
+	BottomLeft
+	Bottom
+	BottomRight
+)
+
+// Directions lists every direction a cell may have a neighbor in.
+var Directions = []Direction{TopLeft, Top, TopRight, Left, Right, BottomLeft, Bottom, BottomRight}
+
+// Opposite returns the direction pointing the other way.
+func (d Direction) Opposite() Direction {
+	return BottomRight - d
+}
+
 type Cell struct {
 	color color.Color
 	start pixel.Vec
 	end   pixel.Vec
 	width float64
+	x     int
+	y     int
 
 	value *shape.Shape
+	ghost *shape.Shape
+	fade  float64
 
 	topLeft     *Cell
 	topRight    *Cell
@@ -35,14 +75,105 @@ func (c *Cell) End() pixel.Vec {
 	return c.end
 }
 
+// X returns the column of the cell, starting from the left.
+func (c *Cell) X() int {
+	return c.x
+}
+
+// Y returns the row of the cell, starting from the top.
+func (c *Cell) Y() int {
+	return c.y
+}
+
+// Kind returns the kind of shape held by the cell, or an
+// empty kind if the cell is empty.
+func (c *Cell) Kind() shape.ShapeKind {
+	if c.value == nil {
+		return ""
+	}
+	return c.value.Kind()
+}
+
+func (c *Cell) Empty() bool {
+	return c.value == nil
+}
+
+func (c *Cell) Neighbor(d Direction) *Cell {
+	switch d {
+	case TopLeft:
+		return c.topLeft
+	case Top:
+		return c.top
+	case TopRight:
+		return c.topRight
+	case Left:
+		return c.left
+	case Right:
+		return c.right
+	case BottomLeft:
+		return c.bottomLeft
+	case Bottom:
+		return c.bottom
+	case BottomRight:
+		return c.bottomRight
+	}
+	return nil
+}
+
+func (c *Cell) setNeighbor(d Direction, n *Cell) {
+	switch d {
+	case TopLeft:
+		c.topLeft = n
+	case Top:
+		c.top = n
+	case TopRight:
+		c.topRight = n
+	case Left:
+		c.left = n
+	case Right:
+		c.right = n
+	case BottomLeft:
+		c.bottomLeft = n
+	case Bottom:
+		c.bottom = n
+	case BottomRight:
+		c.bottomRight = n
+	}
+}
+
 func (c *Cell) Render(context *imdraw.IMDraw) {
-	context.Color = c.color
-	context.Push(c.start, c.end)
-	context.Rectangle(c.width)
+	c.RenderRect(context, c.color, c.width)
 
 	if c.value != nil {
 		c.value.Render(context)
 	}
+
+	if c.ghost != nil {
+		c.ghost.Faded(c.fade).Render(context)
+		c.fade -= fadeStep
+		if c.fade <= 0 {
+			c.ghost = nil
+		}
+	}
+}
+
+// RenderRect draws the outline of the cell with the given color and
+// line thickness, or fills it in if thickness is zero.
+func (c *Cell) RenderRect(context *imdraw.IMDraw, col color.Color, thickness float64) {
+	context.Color = col
+	context.Push(c.start, c.end)
+	context.Rectangle(thickness)
+}
+
+// RenderMark draws a small square of the given color in the
+// center of the cell.
+func (c *Cell) RenderMark(context *imdraw.IMDraw, col color.Color) {
+	center := pixel.V((c.start.X+c.end.X)/2, (c.start.Y+c.end.Y)/2)
+	size := math.Min(c.end.X-c.start.X, c.start.Y-c.end.Y) * markRatio / 2
+
+	context.Color = col
+	context.Push(center.Sub(pixel.V(size, size)), center.Add(pixel.V(size, size)))
+	context.Rectangle(0)
 }
 
 func (c *Cell) Set(shape *shape.Shape) bool {
@@ -53,3 +184,24 @@ func (c *Cell) Set(shape *shape.Shape) bool {
 	c.value = shape
 	return true
 }
+
+// Place sets a new shape of the given kind, sized to fit the cell.
+func (c *Cell) Place(kind shape.ShapeKind) bool {
+	width := c.end.X - c.start.X
+	height := c.start.Y - c.end.Y
+	margin := math.Min(width, height) * shapeMarginRatio
+
+	return c.Set(shape.NewShape(c.start, kind, width, height, margin))
+}
+
+// Remove clears the cell, fading its previous shape out over
+// the next few rendered frames.
+func (c *Cell) Remove() {
+	if c.value == nil {
+		return
+	}
+
+	c.ghost = c.value
+	c.fade = 1
+	c.value = nil
+}
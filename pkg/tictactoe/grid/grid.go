@@ -9,9 +9,7 @@ import (
 
 	"github.com/faiface/pixel"
 	"github.com/faiface/pixel/imdraw"
-	"github.com/faiface/pixel/text"
 
-	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/score"
 	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/shape"
 )
 
@@ -24,14 +22,31 @@ var gridLineColor = colornames.Antiquewhite
 
 type Grid []*Cell
 
+// Size returns the number of cells along each side of the grid.
+func (g Grid) Size() int {
+	return int(math.Sqrt(float64(len(g))))
+}
+
+// At returns the cell in column x and row y, or nil if the
+// coordinates fall outside of the grid.
+func (g Grid) At(x, y int) *Cell {
+	size := g.Size()
+	if x < 0 || y < 0 || x >= size || y >= size {
+		return nil
+	}
+	return g[y*size+x]
+}
+
 func (g Grid) Render(context *imdraw.IMDraw) {
 	for i := range g {
 		g[i].Render(context)
 	}
 
+	size := g.Size()
+
 	// render vertical lines
-	for i := 0; i < MaxCells; i++ {
-		if (i+1)%MaxCells == 0 {
+	for i := 0; i < size; i++ {
+		if (i+1)%size == 0 {
 			continue
 		}
 
@@ -43,7 +58,7 @@ func (g Grid) Render(context *imdraw.IMDraw) {
 
 	// render horizontal grid lines
 	for i := range g {
-		if i%MaxCells != 0 || i/MaxCells+1 == MaxCells {
+		if i%size != 0 || i/size+1 == size {
 			continue
 		}
 
@@ -57,7 +72,29 @@ func (g Grid) Render(context *imdraw.IMDraw) {
 func (g Grid) Reset() {
 	for i := range g {
 		g[i].value = nil
+		g[i].ghost = nil
+	}
+}
+
+// Clone returns a copy of the grid with the same cells and shapes
+// that can be changed without affecting the original grid.
+func (g Grid) Clone() Grid {
+	cells := make([]*Cell, len(g))
+	for i := range g {
+		c := *g[i]
+		c.ghost = nil
+		cells[i] = &c
 	}
+
+	clone := Grid(cells)
+	for _, c := range clone {
+		for _, d := range Directions {
+			if n := c.Neighbor(d); n != nil {
+				c.setNeighbor(d, clone.At(n.x, n.y))
+			}
+		}
+	}
+	return clone
 }
 
 // AtVector receives a vector and returns the cell containing
@@ -72,100 +109,93 @@ func (g Grid) AtVector(v pixel.Vec) *Cell {
 	return nil
 }
 
-func (g Grid) CheckWin(context *imdraw.IMDraw, textContext *text.Text, scoreKeeper score.ScoreKeeper) bool {
-	if len(g) != MaxCells*MaxCells {
-		panic(fmt.Sprintf("malformed grid: expected to check wins on a %d by %d grid, but total grid size was %d", MaxCells, MaxCells, len(g)))
-	}
+// Run is a line of consecutive cells holding the same kind of
+// shape, starting at From and extending Len cells towards Dir.
+type Run struct {
+	From *Cell
+	Dir  Direction
+	Len  int
+}
 
-	// check vertical wins
-	for i := 0; i < MaxCells; i++ {
-		if checkVerticalCount(g[i], g[i].value) >= MaxCells {
-			return drawVerticalWin(context, g[i]) && drawText(textContext, getWinText(g[i])) && setWinScore(g[i], scoreKeeper)
-		}
+// Axes lists one direction along each line a run can follow.
+var Axes = []Direction{Right, Bottom, BottomRight, BottomLeft}
+
+// RunThrough returns the longest run of shapes passing through
+// cell, or nil if no run through cell is at least length long.
+// If exact is true, only runs of exactly length cells count.
+func (g Grid) RunThrough(cell *Cell, length int, exact bool) *Run {
+	if cell == nil || cell.value == nil {
+		return nil
 	}
 
-	// check hortizontal wins
-	for i := range g {
-		if i%MaxCells != 0 {
-			continue
-		}
-		if checkHorizontalCount(g[i], g[i].value) >= MaxCells {
-			return drawHorizontalWin(context, g[i]) && drawText(textContext, getWinText(g[i])) && setWinScore(g[i], scoreKeeper)
+	var longest *Run
+	for _, dir := range Axes {
+		from := cell
+		for prev := from.Neighbor(dir.Opposite()); prev != nil && prev.Kind() == cell.Kind(); prev = prev.Neighbor(dir.Opposite()) {
+			from = prev
 		}
-	}
 
-	// check diagonal wins
-	if checkDiagonalCount(g[0], g[0].value, true) >= MaxCells {
-		return drawDiagonalWin(context, g[0], true) && drawText(textContext, getWinText(g[0])) && setWinScore(g[0], scoreKeeper)
-	}
-	if checkDiagonalCount(g[MaxCells-1], g[MaxCells-1].value, false) >= MaxCells {
-		return drawDiagonalWin(context, g[MaxCells-1], false) && drawText(textContext, getWinText(g[MaxCells-1])) && setWinScore(g[MaxCells-1], scoreKeeper)
+		count, _ := checkCount(from, cell.value, dir)
+		if (count == length || (count > length && !exact)) && (longest == nil || count > longest.Len) {
+			longest = &Run{From: from, Dir: dir, Len: count}
+		}
 	}
+	return longest
+}
 
-	// check draw
+// Full returns true if every cell in the grid holds a shape.
+func (g Grid) Full() bool {
 	for i := range g {
 		if g[i].value == nil {
 			return false
 		}
 	}
-
-	return drawText(textContext, "TIE!")
-}
-
-func drawText(context *text.Text, contents string) bool {
-	context.Dot.X -= context.BoundsOf(contents).W() / 2
-	context.Dot.Y -= context.BoundsOf(contents).H() / 2
-	fmt.Fprintf(context, "%s\n", contents)
-	return true
-}
-
-func setWinScore(cell *Cell, scoreKeeper score.ScoreKeeper) bool {
-	if cell.value == nil {
-		return true
-	}
-
-	scoreKeeper.Add(cell.value.String(), 1)
 	return true
 }
 
-// getWinText returns the string of text presented on a win
-// depending on the value of cell.
-func getWinText(cell *Cell) string {
-	playerWin := 1
-	if cell.value != nil && cell.value.Kind() == shape.CircleShape {
-		playerWin = 2
+// DrawRun strikes through every cell in the given run.
+func (g Grid) DrawRun(context *imdraw.IMDraw, run *Run) bool {
+	switch run.Dir {
+	case Right:
+		return drawHorizontalWin(context, run.From, run.Len)
+	case Bottom:
+		return drawVerticalWin(context, run.From, run.Len)
+	case BottomRight:
+		return drawDiagonalWin(context, run.From, run.Len, true)
+	case BottomLeft:
+		return drawDiagonalWin(context, run.From, run.Len, false)
 	}
 
-	return fmt.Sprintf("PLAYER %d WINS!", playerWin)
+	panic(fmt.Sprintf("unsupported run direction: %v", run.Dir))
 }
 
-func drawHorizontalWin(context *imdraw.IMDraw, from *Cell) bool {
+func drawHorizontalWin(context *imdraw.IMDraw, from *Cell, count int) bool {
 	context.Color = shape.ShapeColor
 	context.Push(pixel.V(from.start.X-gridLineWidth, from.start.Y-((from.start.Y-from.end.Y)/2)))
 	context.Push(pixel.V(from.end.X+gridLineWidth, from.start.Y-((from.start.Y-from.end.Y)/2)))
 	context.Line(gridLineWidth)
 
-	if from.right == nil {
+	if from.right == nil || count <= 1 {
 		return true
 	}
 
-	return drawHorizontalWin(context, from.right)
+	return drawHorizontalWin(context, from.right, count-1)
 }
 
-func drawVerticalWin(context *imdraw.IMDraw, from *Cell) bool {
+func drawVerticalWin(context *imdraw.IMDraw, from *Cell, count int) bool {
 	context.Color = shape.ShapeColor
 	context.Push(pixel.V(from.start.X+((from.end.X-from.start.X)/2), from.start.Y+gridLineWidth))
 	context.Push(pixel.V(from.start.X+((from.end.X-from.start.X)/2), math.Max(from.end.Y-gridLineWidth, 0)))
 	context.Line(gridLineWidth)
 
-	if from.bottom == nil {
+	if from.bottom == nil || count <= 1 {
 		return true
 	}
 
-	return drawVerticalWin(context, from.bottom)
+	return drawVerticalWin(context, from.bottom, count-1)
 }
 
-func drawDiagonalWin(context *imdraw.IMDraw, from *Cell, bottomRight bool) bool {
+func drawDiagonalWin(context *imdraw.IMDraw, from *Cell, count int, bottomRight bool) bool {
 	context.Color = shape.ShapeColor
 	if bottomRight {
 		context.Push(pixel.V(from.start.X, from.start.Y+gridLineWidth))
@@ -176,50 +206,30 @@ func drawDiagonalWin(context *imdraw.IMDraw, from *Cell, bottomRight bool) bool
 	}
 	context.Line(gridLineWidth)
 
-	if (from.bottomRight == nil && bottomRight) || (from.bottomLeft == nil && !bottomRight) {
+	if (from.bottomRight == nil && bottomRight) || (from.bottomLeft == nil && !bottomRight) || count <= 1 {
 		return true
 	}
 	if bottomRight {
-		return drawDiagonalWin(context, from.bottomRight, bottomRight)
-	}
-
-	return drawDiagonalWin(context, from.bottomLeft, bottomRight)
-}
-
-func checkDiagonalCount(cell *Cell, target *shape.Shape, bottomRight bool) int {
-	if cell == nil || target == nil || cell.value == nil || cell.value.Kind() != target.Kind() {
-		return 0
-	}
-	if (cell.bottomRight == nil && bottomRight) || (cell.bottomLeft == nil && !bottomRight) {
-		return 1
+		return drawDiagonalWin(context, from.bottomRight, count-1, bottomRight)
 	}
 
-	if bottomRight {
-		return 1 + checkDiagonalCount(cell.bottomRight, target, bottomRight)
-	}
-	return 1 + checkDiagonalCount(cell.bottomLeft, target, bottomRight)
+	return drawDiagonalWin(context, from.bottomLeft, count-1, bottomRight)
 }
 
-func checkVerticalCount(cell *Cell, target *shape.Shape) int {
+// checkCount returns the number of consecutive cells, starting
+// at cell and moving towards dir, holding the same kind of shape
+// as target, along with the first cell past them, or nil if the
+// run reaches the edge of the grid.
+func checkCount(cell *Cell, target *shape.Shape, dir Direction) (int, *Cell) {
 	if cell == nil || target == nil || cell.value == nil || cell.value.Kind() != target.Kind() {
-		return 0
+		return 0, cell
 	}
-	if cell.bottom == nil {
-		return 1
+	if cell.Neighbor(dir) == nil {
+		return 1, nil
 	}
 
-	return 1 + checkVerticalCount(cell.bottom, target)
-}
-
-func checkHorizontalCount(cell *Cell, target *shape.Shape) int {
-	if cell == nil || target == nil || cell.value == nil || cell.value.Kind() != target.Kind() {
-		return 0
-	}
-	if cell.right == nil {
-		return 1
-	}
-
-	return 1 + checkHorizontalCount(cell.right, target)
+	count, end := checkCount(cell.Neighbor(dir), target, dir)
+	return 1 + count, end
 }
 
 func NewGrid(origin pixel.Vec, maxX, maxY, ncells, mar float64) Grid {
@@ -238,6 +248,8 @@ func NewGrid(origin pixel.Vec, maxX, maxY, ncells, mar float64) Grid {
 				start: start,
 				end:   start.Add(pixel.V(cellWidth, -cellHeight)),
 				width: 3,
+				x:     x,
+				y:     y,
 			})
 		}
 	}
@@ -290,3 +302,33 @@ func NewGrid(origin pixel.Vec, maxX, maxY, ncells, mar float64) Grid {
 
 	return Grid(cells)
 }
+
+// New returns a grid of size cells along each side, one unit wide
+// each, for games played without a window.
+func New(size int) Grid {
+	return NewGrid(pixel.ZV, float64(size), float64(size), float64(size), 0)
+}
+
+// Symmetries returns, for each of the 8 rotations and reflections of
+// a grid of the given size, the index every cell lands on, counting
+// cells row by row.
+func Symmetries(size int) [8][]int {
+	transforms := [8]func(x, y int) (int, int){
+		func(x, y int) (int, int) { return x, y },
+		func(x, y int) (int, int) { return size - 1 - y, x },
+		func(x, y int) (int, int) { return size - 1 - x, size - 1 - y },
+		func(x, y int) (int, int) { return y, size - 1 - x },
+		func(x, y int) (int, int) { return size - 1 - x, y },
+		func(x, y int) (int, int) { return x, size - 1 - y },
+		func(x, y int) (int, int) { return y, x },
+		func(x, y int) (int, int) { return size - 1 - y, size - 1 - x },
+	}
+	symmetries := [8][]int{}
+	for s, transform := range transforms {
+		for i := 0; i < size*size; i++ {
+			x, y := transform(i%size, i/size)
+			symmetries[s] = append(symmetries[s], y*size+x)
+		}
+	}
+	return symmetries
+}
@@ -0,0 +1,168 @@
+package grid
+
+import (
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/shape"
+)
+
+const (
+	// renjuLength is the exact run length the restricted player
+	// must reach under Renju rules.
+	renjuLength = 5
+
+	// renjuDepth bounds how many times a three is re-checked for
+	// being completable only through another forbidden point.
+	renjuDepth = 2
+)
+
+// Restriction names the Renju rule a move would break.
+type Restriction string
+
+const (
+	Unrestricted Restriction = ""
+	DoubleThree  Restriction = "double three"
+	DoubleFour   Restriction = "double four"
+	Overline     Restriction = "overline"
+)
+
+// Forbidden returns the Renju restriction broken by placing a
+// shape of the given kind on the empty cell. A move that makes
+// exactly five in a row is never forbidden.
+func (g Grid) Forbidden(cell *Cell, kind shape.ShapeKind) Restriction {
+	return forbidden(cell, kind, renjuDepth)
+}
+
+func forbidden(cell *Cell, kind shape.ShapeKind, depth int) Restriction {
+	if cell == nil || cell.value != nil {
+		return Unrestricted
+	}
+
+	cell.Place(kind)
+	defer func() { cell.value = nil }()
+
+	overline := false
+	for _, dir := range Axes {
+		switch n := runLength(cell, dir); {
+		case n == renjuLength:
+			return Unrestricted
+		case n > renjuLength:
+			overline = true
+		}
+	}
+	if overline {
+		return Overline
+	}
+
+	fourCount, threeCount := 0, 0
+	for _, dir := range Axes {
+		fourCount += len(fours(cell, dir))
+		if openThree(cell, dir, depth) {
+			threeCount++
+		}
+	}
+
+	if fourCount > 1 {
+		return DoubleFour
+	}
+	if threeCount > 1 {
+		return DoubleThree
+	}
+	return Unrestricted
+}
+
+// fours returns the completions of every four through cell along
+// the axis of dir, keyed by the stones making up each four. A four
+// is a line that a single extra stone turns into exactly five.
+func fours(cell *Cell, dir Direction) map[[2]*Cell][]*Cell {
+	found := map[[2]*Cell][]*Cell{}
+	length := runLength(cell, dir)
+	if length >= renjuLength {
+		return found
+	}
+
+	for _, q := range axisCells(cell, dir, renjuLength-1) {
+		if q.value != nil {
+			continue
+		}
+
+		q.Place(cell.Kind())
+		if runLength(cell, dir) == renjuLength {
+			stones := []*Cell{}
+			for _, c := range runCells(cell, dir) {
+				if c != q {
+					stones = append(stones, c)
+				}
+			}
+			key := [2]*Cell{stones[0], stones[len(stones)-1]}
+			found[key] = append(found[key], q)
+		}
+		q.value = nil
+	}
+	return found
+}
+
+// openThree returns true if a single stone along the axis of dir
+// turns the line through cell into a straight four: a four that
+// can be completed at both of its ends. The extending stone must
+// not itself be forbidden.
+func openThree(cell *Cell, dir Direction, depth int) bool {
+	if depth <= 0 {
+		return false
+	}
+
+	for _, q := range axisCells(cell, dir, renjuLength-1) {
+		if q.value != nil {
+			continue
+		}
+
+		q.Place(cell.Kind())
+		straight := false
+		for _, completions := range fours(cell, dir) {
+			if len(completions) > 1 {
+				straight = true
+			}
+		}
+		q.value = nil
+
+		if straight && forbidden(q, cell.Kind(), depth-1) == Unrestricted {
+			return true
+		}
+	}
+	return false
+}
+
+// runLength returns the number of consecutive cells holding the
+// same shape as cell along the axis of dir, including cell.
+func runLength(cell *Cell, dir Direction) int {
+	forward, _ := checkCount(cell, cell.value, dir)
+	backward, _ := checkCount(cell.Neighbor(dir.Opposite()), cell.value, dir.Opposite())
+	return forward + backward
+}
+
+// runCells returns the run through cell along the axis of dir,
+// in order towards dir.
+func runCells(cell *Cell, dir Direction) []*Cell {
+	from := cell
+	for prev := from.Neighbor(dir.Opposite()); prev != nil && prev.Kind() == cell.Kind(); prev = prev.Neighbor(dir.Opposite()) {
+		from = prev
+	}
+
+	cells := []*Cell{}
+	for c := from; c != nil && c.Kind() == cell.Kind(); c = c.Neighbor(dir) {
+		cells = append(cells, c)
+	}
+	return cells
+}
+
+// axisCells returns the cells up to distance away from cell,
+// on both sides of it along the axis of dir.
+func axisCells(cell *Cell, dir Direction, distance int) []*Cell {
+	cells := []*Cell{}
+	for _, d := range []Direction{dir.Opposite(), dir} {
+		c := cell.Neighbor(d)
+		for i := 0; i < distance && c != nil; i++ {
+			cells = append(cells, c)
+			c = c.Neighbor(d)
+		}
+	}
+	return cells
+}
@@ -0,0 +1,51 @@
+package grid
+
+// Line returns the number of consecutive cells holding the same
+// shape as cell along the axis of dir, including cell, and how many
+// of the two ends of that run are open: next to an empty cell rather
+// than an opponent shape or the edge of the grid.
+func (g Grid) Line(cell *Cell, dir Direction) (count, open int) {
+	if cell == nil || cell.value == nil {
+		return 0, 0
+	}
+
+	forward, end := checkCount(cell, cell.value, dir)
+	backward, start := checkCount(cell.Neighbor(dir.Opposite()), cell.value, dir.Opposite())
+
+	for _, c := range []*Cell{start, end} {
+		if c != nil && c.value == nil {
+			open++
+		}
+	}
+	return forward + backward, open
+}
+
+// Completions returns the empty cells along the axis of dir that,
+// holding the same kind of shape as cell, would extend the line
+// through cell to a run of at least length cells, or of exactly
+// length cells if exact is true.
+func (g Grid) Completions(cell *Cell, dir Direction, length int, exact bool) []*Cell {
+	completions := []*Cell{}
+	if cell == nil || cell.value == nil {
+		return completions
+	}
+
+	for _, q := range axisCells(cell, dir, length-1) {
+		if q.value != nil {
+			continue
+		}
+
+		q.value = cell.value
+		if n := runLength(cell, dir); n == length || (n > length && !exact) {
+			completions = append(completions, q)
+		}
+		q.value = nil
+	}
+	return completions
+}
+
+// Axis returns the cells up to distance away from cell, on both
+// sides of it along the axis of dir.
+func (g Grid) Axis(cell *Cell, dir Direction, distance int) []*Cell {
+	return axisCells(cell, dir, distance)
+}
@@ -0,0 +1,153 @@
+package habits
+
+import (
+	"context"
+	"sync"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/ai"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/shape"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/solver"
+)
+
+// Adaptive plays against the habits of its opponent. Among the moves
+// keeping the result the position is worth with perfect play, it
+// plays the one its opponent is the likeliest to go wrong after,
+// given how they replied to the same position before. It learns
+// those replies as the game goes on.
+type Adaptive struct {
+	// Fallback plays the positions too large to be solved, or of
+	// games the solver does not support.
+	Fallback ai.Bot
+	Oracle   *solver.Oracle
+	// Model holds the habits of the opponent, and is saved to the
+	// file at Path, if set, once the bot is closed.
+	Model *Model
+	Path  string
+
+	// mu guards seen, the history of the game as it was last
+	// learned from.
+	mu   sync.Mutex
+	seen []game.Entry
+}
+
+// Move returns the move for the current side of s. The search is
+// abandoned with an error once ctx is canceled.
+func (a *Adaptive) Move(ctx context.Context, s *game.State) (game.Move, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	p, err := solver.FromState(s)
+	if err != nil || p.Size > solver.MaxSize {
+		return a.Fallback.Move(ctx, s)
+	}
+	a.learn(p, s.History())
+
+	value, _, err := a.Oracle.Evaluate(p)
+	if err != nil {
+		return a.Fallback.Move(ctx, s)
+	}
+	if value == solver.Win {
+		// every winning move is as good against any opponent, so
+		// the quickest is played
+		if moves, ok := a.Oracle.Moves(s); ok {
+			return moves[0], nil
+		}
+	}
+
+	best, bestScore := -1, 0.0
+	for i, kind := range p.Cells {
+		if kind != "" {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return game.Move{}, err
+		}
+
+		after := p.Play(i)
+		reply, _, err := a.Oracle.Evaluate(after)
+		if err != nil {
+			return a.Fallback.Move(ctx, s)
+		}
+		if -reply != value {
+			continue
+		}
+		score, err := a.expect(after)
+		if err != nil {
+			return a.Fallback.Move(ctx, s)
+		}
+		if best < 0 || score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if best < 0 {
+		return a.Fallback.Move(ctx, s)
+	}
+	return p.Move(best), nil
+}
+
+// expect returns the score the side that just moved is expected to
+// get in p, from -1 for a loss to 1 for a win, if its opponent
+// replies as the model predicts and both sides play perfectly after
+// that.
+func (a *Adaptive) expect(p solver.Position) (float64, error) {
+	value, _, err := a.Oracle.Evaluate(p)
+	if err != nil {
+		return 0, err
+	}
+	if value == solver.Loss {
+		// the game was won, or will be whatever the reply
+		return 1, nil
+	}
+
+	score := 0.0
+	for i, probability := range a.Model.Expect(p) {
+		if probability == 0 {
+			continue
+		}
+		after, _, err := a.Oracle.Evaluate(p.Play(i))
+		if err != nil {
+			return 0, err
+		}
+		score += probability * float64(after)
+	}
+	return score, nil
+}
+
+// learn records the moves of the opponent made in history since it
+// was last learned from. The positions they were made in are those
+// of p, the position history leads to, with the shapes placed since
+// taken off.
+func (a *Adaptive) learn(p solver.Position, history []game.Entry) {
+	common := 0
+	for common < len(a.seen) && common < len(history) && a.seen[common].Move == history[common].Move && a.seen[common].Kind == history[common].Kind {
+		common++
+	}
+	a.seen = append([]game.Entry{}, history...)
+
+	before := p
+	before.Cells = append([]shape.ShapeKind{}, p.Cells...)
+	for i := len(history) - 1; i >= common; i-- {
+		entry := history[i]
+		if entry.Choice != "" {
+			continue
+		}
+		index := entry.Y*p.Size + entry.X
+		before.Cells[index] = ""
+		before.Turn = entry.Kind
+		if entry.Kind != p.Turn {
+			a.Model.Observe(before, index)
+		}
+	}
+}
+
+// Close saves the habits of the opponent, if they are kept in a file.
+func (a *Adaptive) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.Path == "" {
+		return nil
+	}
+	return a.Model.Save(a.Path)
+}
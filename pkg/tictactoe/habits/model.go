@@ -0,0 +1,154 @@
+package habits
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/grid"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/record"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/solver"
+)
+
+// prior is the number of times every move is counted as played
+// before any is seen, so moves never seen are still expected now and
+// then.
+const prior = 0.5
+
+// Model records how a player replies to the positions they face.
+// Only positions of boards small enough to be solved are recorded.
+type Model struct {
+	// Replies maps each position, as written by key, to the number
+	// of times each cell was played on in it, by its index in the
+	// orientation the key is written in.
+	Replies map[string]map[int]int `json:"replies"`
+}
+
+func NewModel() *Model {
+	return &Model{Replies: make(map[string]map[int]int)}
+}
+
+// Len returns the number of positions the model knows replies to.
+func (m *Model) Len() int {
+	return len(m.Replies)
+}
+
+// Observe records the player playing on the cell at index in p.
+func (m *Model) Observe(p solver.Position, index int) {
+	if p.Size > solver.MaxSize {
+		return
+	}
+	key, orient := key(p)
+	if m.Replies[key] == nil {
+		m.Replies[key] = make(map[int]int)
+	}
+	m.Replies[key][orient[index]]++
+}
+
+// Expect returns the probability of the player playing on each cell
+// of p. Cells played on more often before are more likely, and every
+// empty cell has some chance of being played on.
+func (m *Model) Expect(p solver.Position) []float64 {
+	key, orient := key(p)
+	replies := m.Replies[key]
+
+	total, empties := 0.0, 0.0
+	for i, kind := range p.Cells {
+		if kind == "" {
+			total += float64(replies[orient[i]])
+			empties++
+		}
+	}
+
+	probabilities := make([]float64, len(p.Cells))
+	for i, kind := range p.Cells {
+		if kind == "" {
+			probabilities[i] = (float64(replies[orient[i]]) + prior) / (total + prior*empties)
+		}
+	}
+	return probabilities
+}
+
+// LearnGame records the moves made in g by the sides played under
+// profile.
+func (m *Model) LearnGame(g *record.Game, profile string) error {
+	_, err := g.Replay(func(s *game.State, move record.Move) {
+		if g.Profiles[move.Side] != profile || move.Choice != "" {
+			return
+		}
+		if p, err := solver.FromState(s); err == nil {
+			m.Observe(p, move.Y*p.Size+move.X)
+		}
+	})
+	return err
+}
+
+// key returns the key of p, the smallest of the ways of writing it
+// under each symmetry of the board, along with the cell each cell
+// of p lands on in that orientation.
+func key(p solver.Position) (string, []int) {
+	best, orient := "", []int(nil)
+	for _, sym := range grid.Symmetries(p.Size) {
+		cells := make([]byte, len(p.Cells))
+		for i, kind := range p.Cells {
+			c := byte('.')
+			if kind != "" {
+				c = strings.ToLower(string(kind))[0]
+			}
+			cells[sym[i]] = c
+		}
+		if k := fmt.Sprintf("%d:%s", p.Length, cells); orient == nil || k < best {
+			best, orient = k, sym
+		}
+	}
+	return best, orient
+}
+
+// Path returns the file the model of profile is kept in, in dir.
+// Profile names cannot lead out of dir.
+func Path(dir, profile string) (string, error) {
+	if profile == "" || strings.ContainsAny(profile, `/\`) || strings.Contains(profile, "..") {
+		return "", fmt.Errorf("invalid profile name %q", profile)
+	}
+	return filepath.Join(dir, profile+".json"), nil
+}
+
+// Load reads the model in the file at path, or returns an empty
+// model if there is no such file yet.
+func Load(path string) (*Model, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return NewModel(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	m := NewModel()
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if m.Replies == nil {
+		m.Replies = make(map[string]map[int]int)
+	}
+	return m, nil
+}
+
+// Save writes m to the file at path, creating its directory if
+// needed.
+func (m *Model) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
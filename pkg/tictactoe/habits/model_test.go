@@ -0,0 +1,28 @@
+package habits
+
+import "testing"
+
+func TestPath(t *testing.T) {
+	tests := []struct {
+		profile string
+		valid   bool
+	}{
+		{profile: "alice", valid: true},
+		{profile: "alice.v2", valid: true},
+		{profile: ""},
+		{profile: "../alice"},
+		{profile: ".."},
+		{profile: "a/b"},
+		{profile: `a\b`},
+	}
+
+	for _, test := range tests {
+		path, err := Path("profiles", test.profile)
+		if test.valid && err != nil {
+			t.Errorf("%q: unexpected error: %v", test.profile, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%q: expected an error, got path %q", test.profile, path)
+		}
+	}
+}
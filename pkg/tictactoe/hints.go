@@ -0,0 +1,213 @@
+package tictactoe
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/ai"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/solver"
+)
+
+const (
+	// hintSearchTime is how long the win rate of every move is
+	// estimated for on boards too large to be solved.
+	hintSearchTime = time.Second
+
+	// hintAlpha is the opacity of the tint filling hinted cells.
+	hintAlpha = 0.35
+	// bestHintWidth is the width of the outline around the best move.
+	bestHintWidth = 4
+)
+
+var (
+	hintKey = pixelgl.KeyH
+
+	winHintColor  = colornames.Limegreen
+	drawHintColor = colornames.Gold
+	lossHintColor = colornames.Crimson
+	bestHintColor = colornames.White
+)
+
+// hint is the evaluation of the move on the cell at x, y for the
+// side to move.
+type hint struct {
+	x     int
+	y     int
+	label string
+	color pixel.RGBA
+	best  bool
+}
+
+// hints overlays every empty cell with the evaluation of playing on
+// it while turned on. Evaluations are computed off the render loop,
+// every time the position changes.
+type hints struct {
+	oracle *solver.Oracle
+	on     bool
+
+	// position identifies the position the hints being shown, or
+	// computed, are for.
+	position string
+	cancel   context.CancelFunc
+	results  chan hintResult
+	current  []hint
+}
+
+type hintResult struct {
+	position string
+	hints    []hint
+}
+
+// handle toggles the hints when their key is pressed, and starts
+// computing them again whenever the position changes.
+func (h *hints) handle(window *pixelgl.Window, state *game.State) {
+	if window.JustPressed(hintKey) {
+		h.on = !h.on
+	}
+	if !h.on {
+		h.stop()
+		return
+	}
+
+	if position := positionKey(state); position != h.position {
+		h.stop()
+		h.position = position
+
+		ctx, cancel := context.WithCancel(context.Background())
+		h.cancel = cancel
+		go func(s *game.State) {
+			hints := computeHints(ctx, s, h.oracle)
+			select {
+			case h.results <- hintResult{position: position, hints: hints}:
+			case <-ctx.Done():
+			}
+		}(state.Clone())
+	}
+
+	select {
+	case result := <-h.results:
+		if result.position == h.position {
+			h.current = result.hints
+		}
+	default:
+	}
+}
+
+// stop cancels any hints being computed, and clears those shown.
+func (h *hints) stop() {
+	if h.cancel != nil {
+		h.cancel()
+		h.cancel = nil
+	}
+	h.position = ""
+	h.current = nil
+}
+
+func (h *hints) render(context *imdraw.IMDraw, textContext *text.Text, state *game.State) {
+	for _, hint := range h.current {
+		cell := state.Grid.At(hint.x, hint.y)
+		if cell == nil || !cell.Empty() {
+			continue
+		}
+
+		cell.RenderRect(context, hint.color.Mul(pixel.Alpha(hintAlpha)), 0)
+		if hint.best {
+			cell.RenderRect(context, bestHintColor, bestHintWidth)
+		}
+
+		center := pixel.V((cell.Start().X+cell.End().X)/2, (cell.Start().Y+cell.End().Y)/2)
+		bounds := textContext.BoundsOf(hint.label)
+		textContext.Dot = center.Sub(pixel.V(bounds.W()/2, bounds.H()/2))
+		fmt.Fprint(textContext, hint.label)
+	}
+}
+
+// computeHints evaluates every move available in s. Positions small
+// enough to be solved are labeled with their outcome and the number
+// of moves until the game ends, e.g. W3, D or L4, and the win rate
+// of every other move is estimated by a tree search.
+func computeHints(ctx context.Context, s *game.State, oracle *solver.Oracle) []hint {
+	if s.Phase() == game.PhaseChoice || s.Phase() == game.PhaseOver {
+		return nil
+	}
+
+	if p, err := solver.FromState(s); err == nil && p.Size <= solver.MaxSize {
+		return solvedHints(ctx, s, p, oracle)
+	}
+
+	hints := []hint{}
+	candidates := (&ai.MCTS{Duration: hintSearchTime}).Search(ctx, s)
+	if ctx.Err() != nil {
+		return nil
+	}
+	for i, c := range candidates {
+		if c.Visits == 0 || c.Move.Choice != "" {
+			continue
+		}
+		hints = append(hints, hint{
+			x:     c.Move.X,
+			y:     c.Move.Y,
+			label: fmt.Sprintf("%.0f%%", c.WinRate()*100),
+			color: pixel.ToRGBA(lossHintColor).Mul(pixel.Alpha(1 - c.WinRate())).Add(pixel.ToRGBA(winHintColor).Mul(pixel.Alpha(c.WinRate()))),
+			best:  i == 0,
+		})
+	}
+	return hints
+}
+
+func solvedHints(ctx context.Context, s *game.State, p solver.Position, oracle *solver.Oracle) []hint {
+	hints := []hint{}
+	best, bestRank := -1, 0
+	for _, move := range s.Legal() {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		value, distance, err := oracle.Evaluate(p.Play(move.Y*p.Size + move.X))
+		if err != nil {
+			return nil
+		}
+
+		// the value of the position reached is for the other side
+		h := hint{x: move.X, y: move.Y}
+		moves := distance + 1
+		rank := 0
+		switch -value {
+		case solver.Win:
+			h.label, h.color, rank = fmt.Sprintf("W%d", moves), pixel.ToRGBA(winHintColor), 2*len(p.Cells)-moves
+		case solver.Loss:
+			h.label, h.color, rank = fmt.Sprintf("L%d", moves), pixel.ToRGBA(lossHintColor), moves-2*len(p.Cells)
+		default:
+			h.label, h.color = "D", pixel.ToRGBA(drawHintColor)
+		}
+
+		if best < 0 || rank > bestRank {
+			best, bestRank = len(hints), rank
+		}
+		hints = append(hints, h)
+	}
+
+	if best >= 0 {
+		hints[best].best = true
+	}
+	return hints
+}
+
+// positionKey returns a string identifying the position reached in s.
+func positionKey(s *game.State) string {
+	var key strings.Builder
+	for _, cell := range s.Grid {
+		key.WriteString(string(cell.Kind()) + ".")
+	}
+	fmt.Fprintf(&key, "%s %d %d", s.Turn(), s.Phase(), len(s.History()))
+	return key.String()
+}
@@ -0,0 +1,45 @@
+package learn
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/grid"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/solver"
+)
+
+// Agent plays the move its model values the most, without searching
+// any further.
+type Agent struct {
+	Model Model
+}
+
+// Move returns the best move in s according to the model. Only
+// positions of the board the model was trained for can be played.
+func (a *Agent) Move(ctx context.Context, s *game.State) (game.Move, error) {
+	p, err := solver.FromState(s)
+	if err != nil {
+		return game.Move{}, err
+	}
+	if err := check(a.Model, p); err != nil {
+		return game.Move{}, err
+	}
+
+	b := fromPosition(p)
+	return p.Move(best(a.Model, b, b.moves())), nil
+}
+
+// Supports returns an error unless every position of games played
+// with rules can be played by the agent. Openings and handicap
+// stones are never played by models.
+func (a *Agent) Supports(rules game.Rules) error {
+	if rules.Opening != game.NoOpening || rules.Handicap.Stones > 0 {
+		return fmt.Errorf("%w: models cannot play openings or handicap stones", solver.ErrUnsupported)
+	}
+	p, err := solver.FromState(game.NewState(grid.New(rules.Variant.Size), rules))
+	if err != nil {
+		return err
+	}
+	return check(a.Model, p)
+}
@@ -0,0 +1,36 @@
+package learn
+
+import (
+	"testing"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+)
+
+func TestAgentSupports(t *testing.T) {
+	tests := []struct {
+		name      string
+		rules     game.Rules
+		supported bool
+	}{
+		{name: "standard", rules: game.Rules{Variant: game.Standard, Opening: game.NoOpening}, supported: true},
+		{name: "center handicap", rules: game.Rules{Variant: game.Standard, Opening: game.NoOpening, Handicap: game.Handicap{Center: true}}, supported: true},
+		{name: "other board", rules: game.Rules{Variant: game.Gomoku, Opening: game.NoOpening}},
+		{name: "captures", rules: game.Rules{Variant: game.Pente, Opening: game.NoOpening}},
+		{name: "opening", rules: game.Rules{Variant: game.Standard, Opening: game.Pie}},
+		{name: "handicap stones", rules: game.Rules{Variant: game.Standard, Opening: game.NoOpening, Handicap: game.Handicap{Stones: 1}}},
+		{name: "handicap length", rules: game.Rules{Variant: game.Standard, Opening: game.NoOpening, Handicap: game.Handicap{WeakLength: 2}}},
+	}
+
+	agent := &Agent{Model: NewTable(3, 3)}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := agent.Supports(test.rules)
+			if test.supported && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !test.supported && err == nil {
+				t.Error("expected the rules to be rejected")
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package learn
+
+import (
+	"fmt"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/shape"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/solver"
+)
+
+// board is a position of the standard game, kept small so it can be
+// played on quickly during training. Cells hold 1 for the shapes of
+// the side to move and 2 for those of its opponent, and are swapped
+// after every move, so every position is seen from the side to move.
+type board struct {
+	size   int
+	length int
+	cells  []int8
+	// stones is the number of shapes placed.
+	stones int
+}
+
+func newBoard(size, length int) *board {
+	return &board{size: size, length: length, cells: make([]int8, size*size)}
+}
+
+// fromPosition returns p as seen from the side to move.
+func fromPosition(p solver.Position) *board {
+	b := newBoard(p.Size, p.Length)
+	for i, kind := range p.Cells {
+		switch {
+		case kind == "":
+			continue
+		case kind == p.Turn:
+			b.cells[i] = 1
+		default:
+			b.cells[i] = 2
+		}
+		b.stones++
+	}
+	return b
+}
+
+func (b *board) clone() *board {
+	c := *b
+	c.cells = append([]int8{}, b.cells...)
+	return &c
+}
+
+// play places a shape of the side to move on cell i, and returns
+// true if it wins the game. The board is then seen from the other
+// side.
+func (b *board) play(i int) bool {
+	b.cells[i] = 1
+	b.stones++
+	won := b.wins(i)
+	b.swap()
+	return won
+}
+
+func (b *board) undo(i int) {
+	b.swap()
+	b.cells[i] = 0
+	b.stones--
+}
+
+// swap hands the move to the other side.
+func (b *board) swap() {
+	for i, c := range b.cells {
+		if c != 0 {
+			b.cells[i] = 3 - c
+		}
+	}
+}
+
+func (b *board) full() bool {
+	return b.stones == len(b.cells)
+}
+
+// wins returns true if the shape on cell i is part of a run long
+// enough to win.
+func (b *board) wins(i int) bool {
+	x, y := i%b.size, i/b.size
+	for _, d := range [][2]int{{1, 0}, {0, 1}, {1, 1}, {-1, 1}} {
+		run := 1
+		for _, sign := range []int{1, -1} {
+			for n := 1; ; n++ {
+				cx, cy := x+sign*d[0]*n, y+sign*d[1]*n
+				if cx < 0 || cy < 0 || cx >= b.size || cy >= b.size || b.cells[cy*b.size+cx] != b.cells[i] {
+					break
+				}
+				run++
+			}
+		}
+		if run >= b.length {
+			return true
+		}
+	}
+	return false
+}
+
+// moves returns the empty cells worth playing on. On boards larger
+// than 4x4, only cells next to a shape already placed are, or the
+// center of an empty board.
+func (b *board) moves() []int {
+	moves := []int{}
+	for i, c := range b.cells {
+		if c == 0 && (b.size <= 4 || b.near(i)) {
+			moves = append(moves, i)
+		}
+	}
+	if len(moves) == 0 && b.stones == 0 {
+		moves = append(moves, b.size/2*b.size+b.size/2)
+	}
+	return moves
+}
+
+func (b *board) near(i int) bool {
+	x, y := i%b.size, i/b.size
+	for cy := y - 1; cy <= y+1; cy++ {
+		for cx := x - 1; cx <= x+1; cx++ {
+			if cx >= 0 && cy >= 0 && cx < b.size && cy < b.size && b.cells[cy*b.size+cx] != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// check returns an error unless the board is one the model was
+// trained for.
+func check(m Model, p solver.Position) error {
+	size, length := m.Board()
+	if p.Size != size || p.Length != length {
+		return fmt.Errorf("%w: model is for boards of size %d needing %d in a row, not size %d needing %d", solver.ErrUnsupported, size, length, p.Size, p.Length)
+	}
+	if p.Turn != shape.CrossShape && p.Turn != shape.CircleShape {
+		return fmt.Errorf("%w: no side is to move", solver.ErrUnsupported)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package learn
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sort"
+)
+
+const (
+	// modelMagic starts every model file.
+	modelMagic = "TTRL"
+
+	// modelVersion is the version of the model format. It must
+	// change whenever the layout of the file, or the way positions
+	// are fed to models, changes, so files written before are
+	// rejected.
+	modelVersion = 1
+)
+
+const (
+	tableModel uint8 = iota + 1
+	networkModel
+)
+
+var (
+	ErrBadModel     = errors.New("not a model file")
+	ErrStaleModel   = errors.New("model file was written by an incompatible version")
+	ErrCorruptModel = errors.New("model file is corrupt")
+)
+
+// modelHeader is written at the start of a model file. It is followed
+// by Count table entries, or by the weights of a network of Count
+// hidden units, and by the CRC-32 checksum of everything before it.
+type modelHeader struct {
+	Magic   [4]byte
+	Version uint16
+	Kind    uint8
+	Size    uint8
+	Length  uint8
+	Count   uint32
+}
+
+type tableEntry struct {
+	Key   uint64
+	Value float64
+}
+
+// Write writes m in its binary format.
+func Write(w io.Writer, m Model) error {
+	var buf bytes.Buffer
+	size, length := m.Board()
+	header := modelHeader{Version: modelVersion, Size: uint8(size), Length: uint8(length)}
+	copy(header.Magic[:], modelMagic)
+
+	switch m := m.(type) {
+	case *Table:
+		header.Kind, header.Count = tableModel, uint32(len(m.values))
+		binary.Write(&buf, binary.BigEndian, header)
+
+		keys := []uint64{}
+		for key := range m.values {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i] < keys[j]
+		})
+		for _, key := range keys {
+			binary.Write(&buf, binary.BigEndian, tableEntry{Key: key, Value: m.values[key]})
+		}
+	case *Network:
+		header.Kind, header.Count = networkModel, uint32(len(m.hidden))
+		binary.Write(&buf, binary.BigEndian, header)
+		for _, unit := range m.hidden {
+			binary.Write(&buf, binary.BigEndian, unit)
+		}
+		binary.Write(&buf, binary.BigEndian, m.output)
+	default:
+		return fmt.Errorf("unknown model %T", m)
+	}
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(buf.Bytes()))
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Save writes m to the file at path.
+func Save(path string, m Model) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if err := Write(w, m); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Read reads a model written by Write, rejecting models of another
+// version and any whose checksum does not match.
+func Read(r io.Reader) (Model, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	header := modelHeader{}
+	if err := binary.Read(bytes.NewReader(data), binary.BigEndian, &header); err != nil || string(header.Magic[:]) != modelMagic {
+		return nil, ErrBadModel
+	}
+	if header.Version != modelVersion {
+		return nil, fmt.Errorf("%w: version %d, expected %d", ErrStaleModel, header.Version, modelVersion)
+	}
+
+	size, length := int(header.Size), int(header.Length)
+	inputs := 2 * size * size
+	body := binary.Size(header)
+	switch header.Kind {
+	case tableModel:
+		body += int(header.Count) * binary.Size(tableEntry{})
+	case networkModel:
+		body += 8 * (int(header.Count)*(inputs+1) + int(header.Count) + 1)
+	default:
+		return nil, fmt.Errorf("%w: unknown kind of model %d", ErrCorruptModel, header.Kind)
+	}
+	if len(data) != body+4 {
+		return nil, fmt.Errorf("%w: expected %d bytes, found %d", ErrCorruptModel, body+4, len(data))
+	}
+	if crc32.ChecksumIEEE(data[:body]) != binary.BigEndian.Uint32(data[body:]) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrCorruptModel)
+	}
+
+	r = bytes.NewReader(data[binary.Size(header):body])
+	if header.Kind == tableModel {
+		t := NewTable(size, length)
+		entries := make([]tableEntry, header.Count)
+		if err := binary.Read(r, binary.BigEndian, entries); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorruptModel, err)
+		}
+		for _, e := range entries {
+			t.values[e.Key] = e.Value
+		}
+		return t, nil
+	}
+
+	n := &Network{size: size, length: length, output: make([]float64, header.Count+1)}
+	for j := 0; j < int(header.Count); j++ {
+		unit := make([]float64, inputs+1)
+		if err := binary.Read(r, binary.BigEndian, unit); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorruptModel, err)
+		}
+		n.hidden = append(n.hidden, unit)
+	}
+	if err := binary.Read(r, binary.BigEndian, n.output); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptModel, err)
+	}
+	return n, nil
+}
+
+// Load reads the model in the file at path.
+func Load(path string) (Model, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	m, err := Read(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
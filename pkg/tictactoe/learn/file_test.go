@@ -0,0 +1,59 @@
+package learn
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// trained returns a model trained by seeded self-play.
+func trained(model Model, seed int64) Model {
+	t := &Trainer{Model: model, Rate: 0.1, Lambda: 0.7, Epsilon: 0.1, Rand: rand.New(rand.NewSource(seed))}
+	for i := 0; i < 200; i++ {
+		t.Play()
+	}
+	return model
+}
+
+func TestWriteRead(t *testing.T) {
+	tests := []struct {
+		name  string
+		model func() Model
+	}{
+		{name: "table", model: func() Model { return NewTable(3, 3) }},
+		{name: "network", model: func() Model { return NewNetwork(3, 3, 8, rand.New(rand.NewSource(1))) }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := trained(test.model(), 1)
+			if !reflect.DeepEqual(m, trained(test.model(), 1)) {
+				t.Error("expected training with the same seed to learn the same model")
+			}
+
+			var buf bytes.Buffer
+			if err := Write(&buf, m); err != nil {
+				t.Fatal(err)
+			}
+			data := buf.Bytes()
+			read, err := Read(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(read, m) {
+				t.Error("expected the model read to equal the one written")
+			}
+
+			corrupt := append([]byte(nil), data...)
+			corrupt[len(corrupt)/2] ^= 0xff
+			if _, err := Read(bytes.NewReader(corrupt)); !errors.Is(err, ErrCorruptModel) {
+				t.Errorf("expected %v, got %v", ErrCorruptModel, err)
+			}
+			if _, err := Read(bytes.NewReader(data[:3])); !errors.Is(err, ErrBadModel) {
+				t.Errorf("expected %v, got %v", ErrBadModel, err)
+			}
+		})
+	}
+}
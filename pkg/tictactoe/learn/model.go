@@ -0,0 +1,168 @@
+package learn
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/grid"
+)
+
+// MaxTableSize is the largest board a table is kept for. Larger
+// boards have too many positions to learn the value of each.
+const MaxTableSize = 4
+
+// Model estimates the value of positions of a board, and learns
+// better estimates from the games it sees.
+type Model interface {
+	// Board returns the size of the board the model is for, and the
+	// number of shapes in a row needed to win on it.
+	Board() (size, length int)
+
+	// value returns how good b is for the side to move, from -1 for
+	// a certain loss to 1 for a certain win.
+	value(b *board) float64
+	// learn moves the value of b towards target by a fraction rate
+	// of the difference between them.
+	learn(b *board, target, rate float64)
+}
+
+// Table keeps the value of every position seen in training. Positions
+// equal under a rotation or reflection of the board share a value.
+type Table struct {
+	size   int
+	length int
+	values map[uint64]float64
+	// symmetries maps every cell to the cell it lands on under each
+	// symmetry of the board.
+	symmetries [8][]int
+}
+
+func (t *Table) Board() (int, int) {
+	return t.size, t.length
+}
+
+// Len returns the number of positions the table holds a value for.
+func (t *Table) Len() int {
+	return len(t.values)
+}
+
+func (t *Table) value(b *board) float64 {
+	return t.values[t.key(b)]
+}
+
+func (t *Table) learn(b *board, target, rate float64) {
+	key := t.key(b)
+	t.values[key] += rate * (target - t.values[key])
+}
+
+// key returns the smallest of the base-3 numbers written by the
+// cells of b under each symmetry of the board.
+func (t *Table) key(b *board) uint64 {
+	key := uint64(math.MaxUint64)
+	for _, sym := range t.symmetries {
+		k := uint64(0)
+		for i := len(sym) - 1; i >= 0; i-- {
+			k = k*3 + uint64(b.cells[sym[i]])
+		}
+		if k < key {
+			key = k
+		}
+	}
+	return key
+}
+
+func NewTable(size, length int) *Table {
+	return &Table{size: size, length: length, values: make(map[uint64]float64), symmetries: grid.Symmetries(size)}
+}
+
+// Network estimates the value of positions with a neural network of
+// a single hidden layer. Its inputs tell which cells hold a shape of
+// the side to move and which hold one of its opponent.
+type Network struct {
+	size   int
+	length int
+
+	// hidden holds, for each hidden unit, its bias followed by the
+	// weight of each input, and output the bias of the output
+	// followed by the weight of each hidden unit.
+	hidden [][]float64
+	output []float64
+}
+
+func (n *Network) Board() (int, int) {
+	return n.size, n.length
+}
+
+// Hidden returns the number of hidden units of the network.
+func (n *Network) Hidden() int {
+	return len(n.hidden)
+}
+
+func (n *Network) value(b *board) float64 {
+	value, _ := n.forward(b)
+	return value
+}
+
+// forward returns the output of the network for b, along with the
+// activation of each hidden unit. Only the inputs of occupied cells
+// are non-zero, so only their weights are summed.
+func (n *Network) forward(b *board) (float64, []float64) {
+	activations := make([]float64, len(n.hidden))
+	sum := n.output[0]
+	for j, unit := range n.hidden {
+		h := unit[0]
+		for i, c := range b.cells {
+			if c != 0 {
+				h += unit[n.input(i, c)]
+			}
+		}
+		activations[j] = math.Tanh(h)
+		sum += n.output[j+1] * activations[j]
+	}
+	return math.Tanh(sum), activations
+}
+
+// learn takes a step of gradient descent on the squared difference
+// between the value of b and target.
+func (n *Network) learn(b *board, target, rate float64) {
+	value, activations := n.forward(b)
+	delta := (target - value) * (1 - value*value)
+
+	for j, unit := range n.hidden {
+		h := activations[j]
+		step := rate * delta * n.output[j+1] * (1 - h*h)
+		unit[0] += step
+		for i, c := range b.cells {
+			if c != 0 {
+				unit[n.input(i, c)] += step
+			}
+		}
+		n.output[j+1] += rate * delta * h
+	}
+	n.output[0] += rate * delta
+}
+
+// input returns the index, among the weights of a hidden unit, of the
+// input telling that cell i holds c.
+func (n *Network) input(i int, c int8) int {
+	return 1 + int(c-1)*n.size*n.size + i
+}
+
+// NewNetwork returns a network with the given number of hidden units,
+// its weights drawn at random from r.
+func NewNetwork(size, length, hidden int, r *rand.Rand) *Network {
+	n := &Network{size: size, length: length, output: make([]float64, hidden+1)}
+	inputs := 2 * size * size
+	scale := 1 / math.Sqrt(float64(inputs))
+	for j := 0; j < hidden; j++ {
+		unit := make([]float64, inputs+1)
+		for i := range unit {
+			unit[i] = (r.Float64()*2 - 1) * scale
+		}
+		n.hidden = append(n.hidden, unit)
+	}
+	for j := range n.output {
+		n.output[j] = (r.Float64()*2 - 1) / math.Sqrt(float64(hidden))
+	}
+	return n
+}
@@ -0,0 +1,121 @@
+package learn
+
+import (
+	"math/rand"
+)
+
+// Trainer improves a model by having it play against itself, learning
+// after every game with TD(λ): the value of each position is moved
+// towards a mix of the values of the positions that followed it,
+// weighted by λ, and of the outcome of the game.
+type Trainer struct {
+	Model Model
+	// Rate is the fraction of the difference with its target each
+	// value is moved by.
+	Rate float64
+	// Lambda weighs the outcome of the game against the value of the
+	// next position, from 0 for the next position only to 1 for the
+	// outcome only.
+	Lambda float64
+	// Epsilon is the probability of playing a random move instead of
+	// the best one, so positions the model thinks poorly of are
+	// still explored.
+	Epsilon float64
+	// Rand is the source of every random move, so training with the
+	// same seed learns the same values.
+	Rand *rand.Rand
+}
+
+// Play plays a game of the model against itself, and learns from it.
+func (t *Trainer) Play() {
+	size, length := t.Model.Board()
+	b := newBoard(size, length)
+
+	positions := []*board{b.clone()}
+	outcome := 0.0
+	for {
+		moves := b.moves()
+		i := best(t.Model, b, moves)
+		if t.Rand.Float64() < t.Epsilon {
+			i = moves[t.Rand.Intn(len(moves))]
+		}
+		won := b.play(i)
+		positions = append(positions, b.clone())
+		if won {
+			// the side to move has lost
+			outcome = -1
+			break
+		}
+		if b.full() {
+			break
+		}
+	}
+
+	values := make([]float64, len(positions))
+	for i, p := range positions[:len(positions)-1] {
+		values[i] = t.Model.value(p)
+	}
+	values[len(values)-1] = outcome
+
+	// each target is for the side to move, so the values of the
+	// positions that follow are negated
+	target := outcome
+	for i := len(positions) - 2; i >= 0; i-- {
+		target = -((1-t.Lambda)*values[i+1] + t.Lambda*target)
+		t.Model.learn(positions[i], target, t.Rate)
+	}
+}
+
+// Evaluate plays games of the model against a player making random
+// moves drawn from r, taking turns at moving first, and returns the
+// number of games the model won, drew and lost.
+func Evaluate(m Model, games int, r *rand.Rand) (wins, draws, losses int) {
+	size, length := m.Board()
+	for n := 0; n < games; n++ {
+		b := newBoard(size, length)
+		model := n%2 == 0
+		for {
+			moves := b.moves()
+			i := moves[r.Intn(len(moves))]
+			if model {
+				i = best(m, b, moves)
+			}
+			if b.play(i) {
+				if model {
+					wins++
+				} else {
+					losses++
+				}
+				break
+			}
+			if b.full() {
+				draws++
+				break
+			}
+			model = !model
+		}
+	}
+	return wins, draws, losses
+}
+
+// best returns the move the model thinks best for the side to move
+// in b, among moves. Winning moves are always best.
+func best(m Model, b *board, moves []int) int {
+	best, bestValue := moves[0], -2.0
+	for _, i := range moves {
+		value := 1.0
+		if !b.play(i) {
+			// the value of the position reached is for the other side
+			value = -m.value(b)
+			if b.full() {
+				value = 0
+			}
+		}
+		b.undo(i)
+
+		if value > bestValue {
+			best, bestValue = i, value
+		}
+	}
+	return best
+}
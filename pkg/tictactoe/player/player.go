@@ -0,0 +1,115 @@
+package player
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/ai"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+)
+
+// Player decides the moves of one side of a game.
+type Player interface {
+	// NextMove returns the move to make in s. The state belongs to
+	// the player, which may play on it while deciding. NextMove
+	// should return early with an error once ctx is done.
+	NextMove(ctx context.Context, s *game.State) (game.Move, error)
+}
+
+// Observer is a player that needs to know about moves made by the
+// other side, such as a remote peer playing its own copy of a game.
+type Observer interface {
+	Observe(m game.Move) error
+}
+
+// Ponderer is a player that can think about the game while the
+// other side is to move.
+type Ponderer interface {
+	// Ponder thinks about s until ctx is done. The state belongs to
+	// the player, as with NextMove.
+	Ponder(ctx context.Context, s *game.State)
+}
+
+// Human is a player whose moves are sent to it from an input
+// device, such as the mouse or keyboard of the game window. A move
+// sent before NextMove starts waiting is kept for it, as long as it
+// was sent after Ready.
+type Human struct {
+	moves chan game.Move
+}
+
+// Ready discards any move sent before the player is asked for its
+// next one. It must be called before NextMove, and before any move
+// meant for it can be sent.
+func (h *Human) Ready() {
+	select {
+	case <-h.moves:
+	default:
+	}
+}
+
+// Send hands a move to the player, unless one is already waiting to
+// be taken. It returns false if the move was not taken.
+func (h *Human) Send(m game.Move) bool {
+	select {
+	case h.moves <- m:
+		return true
+	default:
+		return false
+	}
+}
+
+func (h *Human) NextMove(ctx context.Context, s *game.State) (game.Move, error) {
+	select {
+	case m := <-h.moves:
+		return m, nil
+	case <-ctx.Done():
+		return game.Move{}, ctx.Err()
+	}
+}
+
+func NewHuman() *Human {
+	return &Human{moves: make(chan game.Move, 1)}
+}
+
+// Computer is a player whose moves are chosen by the built-in AI.
+type Computer struct {
+	Bot ai.Bot
+	// Delay is the least amount of time the computer appears to
+	// think before making a move.
+	Delay time.Duration
+	// Pondering keeps the computer thinking about the game while
+	// the other side is to move, if its bot is able to.
+	Pondering bool
+}
+
+func (c *Computer) NextMove(ctx context.Context, s *game.State) (game.Move, error) {
+	wait := time.After(c.Delay)
+	move, err := c.Bot.Move(ctx, s)
+	if err != nil {
+		return game.Move{}, err
+	}
+
+	select {
+	case <-wait:
+		return move, nil
+	case <-ctx.Done():
+		return game.Move{}, ctx.Err()
+	}
+}
+
+func (c *Computer) Ponder(ctx context.Context, s *game.State) {
+	if ponderer, ok := c.Bot.(ai.Ponderer); ok && c.Pondering {
+		ponderer.Ponder(ctx, s)
+	}
+}
+
+// Close closes the bot of the computer, if it needs closing, such
+// as an engine running in another process.
+func (c *Computer) Close() error {
+	if closer, ok := c.Bot.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package player
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+)
+
+func TestHuman(t *testing.T) {
+	h := NewHuman()
+	stale, move := game.Move{X: 0, Y: 0}, game.Move{X: 1, Y: 1}
+
+	// a click made while the player was not asked for a move
+	h.Send(stale)
+	h.Ready()
+	// a click made before NextMove starts waiting
+	if !h.Send(move) {
+		t.Fatal("expected the move to be kept until it is taken")
+	}
+	if h.Send(stale) {
+		t.Error("expected a second move to be refused")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	got, err := h.NextMove(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != move {
+		t.Errorf("expected %v, got %v", move, got)
+	}
+}
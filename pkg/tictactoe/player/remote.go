@@ -0,0 +1,111 @@
+package player
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+)
+
+// Remote is a player on the other end of a network connection.
+// Both ends exchange their own moves, one per line, as either
+// "place <x> <y>" or "choose <choice>".
+type Remote struct {
+	conn  net.Conn
+	lines chan string
+	err   error
+}
+
+// Dial connects to a remote player listening on addr.
+func Dial(addr string) (*Remote, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return newRemote(conn), nil
+}
+
+// Listen waits for a remote player to connect on addr.
+func Listen(addr string) (*Remote, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer listener.Close()
+
+	conn, err := listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return newRemote(conn), nil
+}
+
+func (r *Remote) NextMove(ctx context.Context, s *game.State) (game.Move, error) {
+	select {
+	case line, ok := <-r.lines:
+		if !ok && r.err != nil {
+			return game.Move{}, fmt.Errorf("remote player disconnected: %v", r.err)
+		}
+		if !ok {
+			return game.Move{}, fmt.Errorf("remote player disconnected")
+		}
+		return ParseMove(line)
+	case <-ctx.Done():
+		return game.Move{}, ctx.Err()
+	}
+}
+
+// Observe sends a move made by the local side to the remote player.
+func (r *Remote) Observe(m game.Move) error {
+	_, err := fmt.Fprintln(r.conn, FormatMove(m))
+	return err
+}
+
+func (r *Remote) Close() error {
+	return r.conn.Close()
+}
+
+// FormatMove returns the line sent over the network for m.
+func FormatMove(m game.Move) string {
+	if m.Choice != "" {
+		return fmt.Sprintf("choose %s", m.Choice)
+	}
+	return fmt.Sprintf("place %d %d", m.X, m.Y)
+}
+
+// ParseMove parses a line formatted by FormatMove.
+func ParseMove(line string) (game.Move, error) {
+	verb, args, _ := strings.Cut(strings.TrimSpace(line), " ")
+	switch verb {
+	case "choose":
+		return game.Move{Choice: game.Choice(args)}, nil
+	case "place":
+		m := game.Move{}
+		if _, err := fmt.Sscanf(args, "%d %d", &m.X, &m.Y); err != nil {
+			return game.Move{}, fmt.Errorf("invalid move %q: %v", line, err)
+		}
+		return m, nil
+	}
+
+	return game.Move{}, fmt.Errorf("invalid move %q", line)
+}
+
+func newRemote(conn net.Conn) *Remote {
+	r := &Remote{
+		conn:  conn,
+		lines: make(chan string),
+	}
+
+	go func() {
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			r.lines <- scanner.Text()
+		}
+		r.err = scanner.Err()
+		close(r.lines)
+	}()
+	return r
+}
@@ -0,0 +1,250 @@
+package tictactoe
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+	"golang.org/x/image/colornames"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/ai"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/book"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/engine"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/habits"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/learn"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/player"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/solver"
+)
+
+// computerDelay is the least amount of time the computer appears
+// to think before making a move.
+const computerDelay = 500 * time.Millisecond
+
+var cursorColor = colornames.Gold
+
+// choiceKeys maps opening choices to the key used to make them.
+var choiceKeys = map[game.Choice]pixelgl.Button{
+	game.ChoiceSwap:     pixelgl.KeyS,
+	game.ChoiceKeep:     pixelgl.KeyK,
+	game.ChoicePlaceTwo: pixelgl.KeyP,
+}
+
+// input turns events from the game window into moves for a
+// human player.
+type input interface {
+	handle(window *pixelgl.Window, state *game.State)
+	render(context *imdraw.IMDraw, state *game.State)
+}
+
+// mouse places shapes on the cells clicked in the window.
+type mouse struct {
+	human *player.Human
+}
+
+func (m *mouse) handle(window *pixelgl.Window, state *game.State) {
+	if window.JustPressed(pixelgl.MouseButtonLeft) {
+		if cell := state.Grid.AtVector(window.MousePosition()); cell != nil {
+			m.human.Send(game.Move{X: cell.X(), Y: cell.Y()})
+		}
+	}
+	handleChoiceKeys(window, m.human)
+}
+
+func (m *mouse) render(context *imdraw.IMDraw, state *game.State) {}
+
+// keyboard moves a cursor around the grid with the arrow keys, and
+// places shapes under it with the enter or space keys.
+type keyboard struct {
+	human *player.Human
+	x     int
+	y     int
+}
+
+func (k *keyboard) handle(window *pixelgl.Window, state *game.State) {
+	size := state.Grid.Size()
+	switch {
+	case window.JustPressed(pixelgl.KeyLeft):
+		k.x = (k.x + size - 1) % size
+	case window.JustPressed(pixelgl.KeyRight):
+		k.x = (k.x + 1) % size
+	case window.JustPressed(pixelgl.KeyUp):
+		k.y = (k.y + size - 1) % size
+	case window.JustPressed(pixelgl.KeyDown):
+		k.y = (k.y + 1) % size
+	case window.JustPressed(pixelgl.KeyEnter), window.JustPressed(pixelgl.KeySpace):
+		k.human.Send(game.Move{X: k.x, Y: k.y})
+	}
+	handleChoiceKeys(window, k.human)
+}
+
+func (k *keyboard) render(context *imdraw.IMDraw, state *game.State) {
+	state.Grid.At(k.x, k.y).RenderMark(context, cursorColor)
+}
+
+func handleChoiceKeys(window *pixelgl.Window, human *player.Human) {
+	for choice, key := range choiceKeys {
+		if window.JustPressed(key) {
+			human.Send(game.Move{Choice: choice})
+		}
+	}
+}
+
+// newPlayer creates the player described by spec, which is one of
+// "mouse", "keyboard", "ai" or "ai:<difficulty>" as accepted by
+// ai.ParseDifficulty, "mcts" or "mcts:<settings>" as accepted by
+// ai.ParseMCTS, "learned:<path>" to play with a model trained by
+// "tictactoe train", "engine:<command>" as accepted by engine.Launch to
+// play with an engine process, "adaptive" or "adaptive:<difficulty>"
+// to play against the habits of the other side, "remote:<addr>" to
+// connect to a remote player, or "listen:<addr>" to wait for one to
+// connect. The built-in computer players also accept a "ponder"
+// setting, e.g. "ai:expert,ponder", to keep thinking while the other
+// side is to move. Players controlled from the game window are
+// returned with their input. Computer players draw their random
+// choices from seed.
+func newPlayer(spec string, seed int64, c computer) (player.Player, input, error) {
+	kind, arg, _ := strings.Cut(spec, ":")
+	ponder := false
+	if kind == "ai" || kind == "mcts" {
+		arg, ponder = cutSetting(arg, "ponder")
+	}
+	switch kind {
+	case "mouse":
+		human := player.NewHuman()
+		return human, &mouse{human: human}, nil
+	case "keyboard":
+		human := player.NewHuman()
+		return human, &keyboard{human: human}, nil
+	case "ai", "mcts", "learned":
+		bot, err := ai.ParseBot(kind+":"+arg, seed)
+		if err != nil {
+			return nil, nil, err
+		}
+		if agent, ok := bot.(*learn.Agent); ok {
+			if err := agent.Supports(c.rules); err != nil {
+				return nil, nil, fmt.Errorf("%s: %v", arg, err)
+			}
+		}
+		if m, ok := bot.(*ai.Minimax); ok {
+			m.Oracle = c.oracle
+			m.Weights = c.weights
+		}
+		if c.openings != nil {
+			bot = &book.Bot{Book: c.openings, Bot: bot, Rand: rand.New(rand.NewSource(seed))}
+		}
+		return &player.Computer{Bot: bot, Delay: computerDelay, Pondering: ponder}, nil, nil
+	case "adaptive":
+		d := ai.Expert
+		if arg != "" {
+			var err error
+			if d, err = ai.ParseDifficulty(arg); err != nil {
+				return nil, nil, err
+			}
+		}
+		fallback := ai.NewMinimax(d, seed)
+		fallback.Oracle = c.oracle
+		fallback.Weights = c.weights
+
+		model := habits.NewModel()
+		if c.habits != "" {
+			var err error
+			if model, err = habits.Load(c.habits); err != nil {
+				return nil, nil, err
+			}
+		}
+		bot := &habits.Adaptive{Fallback: fallback, Oracle: c.oracle, Model: model, Path: c.habits}
+		return &player.Computer{Bot: bot, Delay: computerDelay}, nil, nil
+	case "engine":
+		if err := engine.Supports(c.rules); err != nil {
+			return nil, nil, err
+		}
+		e, err := engine.Launch(arg)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &player.Computer{Bot: e, Delay: computerDelay}, nil, nil
+	case "remote":
+		remote, err := player.Dial(arg)
+		return remote, nil, err
+	case "listen":
+		fmt.Printf("waiting for a remote player to connect on %s...\n", arg)
+		remote, err := player.Listen(arg)
+		return remote, nil, err
+	}
+
+	return nil, nil, fmt.Errorf("unknown player %q", spec)
+}
+
+// computer holds what computer players are created with.
+type computer struct {
+	// rules are the rules of the games played.
+	rules game.Rules
+	// oracle is asked for the moves of positions it can solve.
+	oracle *solver.Oracle
+	// weights, if set, are the weights positions are evaluated with.
+	weights *ai.Weights
+	// openings, if set, is the book of moves played in the opening
+	// instead of searching.
+	openings *book.Book
+	// habits is the file the habits of the human players are kept
+	// in, or empty to only learn them for as long as the game is
+	// open.
+	habits string
+}
+
+// cutSetting removes setting from a comma-separated list of
+// settings, returning the remaining list and whether it was found.
+func cutSetting(settings, setting string) (string, bool) {
+	kept, found := []string{}, false
+	for _, s := range strings.Split(settings, ",") {
+		switch {
+		case s == setting:
+			found = true
+		case s != "":
+			kept = append(kept, s)
+		}
+	}
+	return strings.Join(kept, ","), found
+}
+
+// turn is a pending request for the next move of a side. Canceling
+// it stops the side from deciding its move, and every other side
+// from pondering.
+type turn struct {
+	side   int
+	cancel context.CancelFunc
+	moves  chan move
+}
+
+type move struct {
+	move game.Move
+	err  error
+}
+
+// requestMove asks the player of side for its move in state, while
+// every other player able to ponders.
+func requestMove(players []player.Player, side int, state *game.State) *turn {
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &turn{side: side, cancel: cancel, moves: make(chan move, 1)}
+
+	// clicks made before the move was asked for are not moves, while
+	// those made from now on are kept until the player takes them
+	if human, ok := players[side].(*player.Human); ok {
+		human.Ready()
+	}
+	go func(s *game.State) {
+		m, err := players[side].NextMove(ctx, s)
+		t.moves <- move{move: m, err: err}
+	}(state.Clone())
+	for i, p := range players {
+		if ponderer, ok := p.(player.Ponderer); ok && i != side {
+			go ponderer.Ponder(ctx, state.Clone())
+		}
+	}
+	return t
+}
@@ -0,0 +1,200 @@
+package record
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/grid"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/shape"
+)
+
+// fileExt is the extension given to saved game files.
+const fileExt = ".json"
+
+// Move is a single move in a saved game.
+type Move struct {
+	Side   string `json:"side"`
+	Player string `json:"player,omitempty"`
+	Kind   string `json:"kind,omitempty"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Choice string `json:"choice,omitempty"`
+	// Comment is the commentary shown on the move, if any.
+	Comment string `json:"comment,omitempty"`
+}
+
+// Game is a finished game, as written to disk.
+type Game struct {
+	Variant  string        `json:"variant"`
+	Opening  string        `json:"opening"`
+	Handicap game.Handicap `json:"handicap"`
+	Sides    []string      `json:"sides"`
+	// Teams lists the members of each side in team games.
+	Teams map[string][]string `json:"teams,omitempty"`
+	// Profiles maps the sides played by a human with a profile to
+	// the name of the profile.
+	Profiles map[string]string `json:"profiles,omitempty"`
+	Moves    []Move            `json:"moves"`
+	// Winner is the name of the winning side, or empty on a tie.
+	Winner string    `json:"winner"`
+	Played time.Time `json:"played"`
+}
+
+// FromState records the game played in s.
+func FromState(s *game.State) *Game {
+	g := &Game{
+		Variant:  s.Rules.Variant.Name,
+		Opening:  string(s.Rules.Opening),
+		Handicap: s.Rules.Handicap,
+		Sides:    []string{},
+		Moves:    []Move{},
+		Played:   time.Now(),
+	}
+
+	for _, side := range s.Sides() {
+		g.Sides = append(g.Sides, side.Name)
+		if s.Rules.Teams {
+			if g.Teams == nil {
+				g.Teams = map[string][]string{}
+			}
+			g.Teams[side.Name] = side.Members
+		}
+	}
+	for _, entry := range s.History() {
+		g.Moves = append(g.Moves, Move{
+			Side:   entry.Side,
+			Player: entry.Player,
+			Kind:   string(entry.Kind),
+			X:      entry.X,
+			Y:      entry.Y,
+			Choice: string(entry.Choice),
+		})
+	}
+	if result := s.Result(); result != nil && result.Winner != "" {
+		g.Winner = s.SideOf(result.Winner).Name
+	}
+	return g
+}
+
+// Move returns the move as played in a game.
+func (m Move) Move() game.Move {
+	return game.Move{X: m.X, Y: m.Y, Choice: game.Choice(m.Choice)}
+}
+
+// starter returns the name of the side that opened g: the one
+// placing crosses, or, in games saved before kinds were recorded,
+// the one making the first move after the handicap stones, which
+// follow the setup moves of the game.
+func (g *Game) starter(setup int) string {
+	for _, m := range g.Moves {
+		if m.Kind == string(shape.CrossShape) {
+			return m.Side
+		}
+	}
+	if first := setup + g.Handicap.Stones; first < len(g.Moves) {
+		return g.Moves[first].Side
+	}
+	return ""
+}
+
+// Replay plays g again on a new state, calling visit, if set, with
+// the state before each move and the move made, and returns the
+// state the game ended in. Moves made while setting up the game
+// are not visited. The state must not be changed by visit.
+func (g *Game) Replay(visit func(s *game.State, m Move)) (*game.State, error) {
+	variant, err := game.VariantByName(g.Variant)
+	if err != nil {
+		return nil, err
+	}
+	// games saved before openings were played have none recorded
+	opening := game.NoOpening
+	if g.Opening != "" {
+		if opening, err = game.OpeningByName(g.Opening); err != nil {
+			return nil, err
+		}
+	}
+
+	s := game.NewState(grid.New(variant.Size), game.Rules{
+		Variant:  variant,
+		Opening:  opening,
+		Handicap: g.Handicap,
+		Teams:    g.Teams != nil,
+	})
+	setup := len(s.History())
+	if starter := g.starter(setup); starter != "" {
+		for i, side := range s.Sides() {
+			if side.Name == starter {
+				s.Reset(i)
+			}
+		}
+	}
+
+	for i, m := range g.Moves {
+		if i < setup {
+			continue
+		}
+		if visit != nil {
+			visit(s, m)
+		}
+		if err := s.Apply(m.Move()); err != nil {
+			return nil, fmt.Errorf("move %d of %s: %v", i+1, m.Side, err)
+		}
+	}
+	return s, nil
+}
+
+// Save writes g to a new file in dir, named after the time it
+// was played.
+func Save(dir string, g *Game) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	name := g.Played.Format("20060102-150405.000000000") + fileExt
+	return os.WriteFile(filepath.Join(dir, name), data, 0644)
+}
+
+func Load(path string) (*Game, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Game{}
+	if err := json.Unmarshal(data, g); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return g, nil
+}
+
+// LoadDir loads every game saved in dir, oldest first.
+func LoadDir(dir string) ([]*Game, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+fileExt))
+	if err != nil {
+		return nil, err
+	}
+
+	games := []*Game{}
+	for _, path := range paths {
+		g, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		games = append(games, g)
+	}
+
+	sort.Slice(games, func(i, j int) bool {
+		return games[i].Played.Before(games[j].Played)
+	})
+	return games, nil
+}
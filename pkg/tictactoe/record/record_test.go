@@ -0,0 +1,62 @@
+package record
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/grid"
+)
+
+func TestReplay(t *testing.T) {
+	tests := []struct {
+		name     string
+		handicap string
+		starter  int
+		moves    []game.Move
+	}{
+		{
+			name:  "no handicap",
+			moves: []game.Move{{X: 7, Y: 7}, {X: 8, Y: 8}, {X: 6, Y: 7}},
+		},
+		{
+			name:    "second player starts",
+			starter: 1,
+			moves:   []game.Move{{X: 7, Y: 7}, {X: 8, Y: 8}, {X: 6, Y: 7}},
+		},
+		{
+			name:     "handicap stone",
+			handicap: "weak=2,stones=1",
+			moves:    []game.Move{{X: 3, Y: 3}, {X: 7, Y: 7}, {X: 8, Y: 8}, {X: 6, Y: 7}},
+		},
+		{
+			name:     "handicap stone and center",
+			handicap: "weak=2,stones=2,center",
+			moves:    []game.Move{{X: 3, Y: 3}, {X: 4, Y: 4}, {X: 7, Y: 8}, {X: 8, Y: 8}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handicap, err := game.ParseHandicap(test.handicap)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := game.NewState(grid.New(game.Gomoku.Size), game.Rules{Variant: game.Gomoku, Handicap: handicap})
+			s.Reset(test.starter)
+			for _, m := range test.moves {
+				if err := s.Apply(m); err != nil {
+					t.Fatalf("move %v: %v", m, err)
+				}
+			}
+
+			replayed, err := FromState(s).Replay(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := FromState(replayed).Moves, FromState(s).Moves; !reflect.DeepEqual(got, want) {
+				t.Errorf("replayed moves differ:\ngot  %+v\nwant %+v", got, want)
+			}
+		})
+	}
+}
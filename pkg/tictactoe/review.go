@@ -0,0 +1,234 @@
+package tictactoe
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+	"golang.org/x/image/colornames"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/commentary"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/review"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/solver"
+)
+
+const (
+	// reviewSearchTime is how long every position of a game is
+	// searched for on boards too large to be solved.
+	reviewSearchTime = 500 * time.Millisecond
+
+	// reviewMoveWidth is the width of the outline around the move
+	// being reviewed.
+	reviewMoveWidth = 4
+)
+
+var (
+	reviewKey = pixelgl.KeyR
+
+	goodMoveColor       = colornames.Limegreen
+	inaccurateMoveColor = colornames.Gold
+	blunderColor        = colornames.Crimson
+)
+
+// reviewer steps through the moves of a finished game, showing how
+// each was judged by an analysis of the game running off the render
+// loop. The game is stepped through by taking moves back and playing
+// them again on the state shown.
+type reviewer struct {
+	oracle *solver.Oracle
+	on     bool
+
+	// moves holds every move of the game reviewed, and shown the
+	// number of them played on the state shown.
+	moves []game.Move
+	shown int
+
+	cancel context.CancelFunc
+	// mu guards the progress of the analysis and its results, and
+	// analysis, which tells the analyses of earlier games apart.
+	mu       sync.Mutex
+	analysis int
+	done     int
+	total    int
+	steps    []review.Step
+	err      error
+}
+
+// handle starts the review of a finished game when its key is
+// pressed, and steps through the moves of the game reviewed with
+// the left and right arrow keys until it is pressed again.
+func (r *reviewer) handle(window *pixelgl.Window, state *game.State) {
+	if !r.on {
+		if state.Phase() == game.PhaseOver && window.JustPressed(reviewKey) {
+			r.start(state)
+		}
+		return
+	}
+
+	switch {
+	case window.JustPressed(reviewKey), window.JustPressed(pixelgl.KeyEscape):
+		for r.shown < len(r.moves) {
+			r.forward(state)
+		}
+		r.on = false
+	case window.JustPressed(pixelgl.KeyLeft):
+		r.back(state)
+	case window.JustPressed(pixelgl.KeyRight):
+		r.forward(state)
+	case window.JustPressed(pixelgl.KeyHome):
+		for r.back(state) {
+		}
+	case window.JustPressed(pixelgl.KeyEnd):
+		for r.shown < len(r.moves) {
+			r.forward(state)
+		}
+	}
+}
+
+// start reviews the game played in state, analyzing it unless it
+// already was.
+func (r *reviewer) start(state *game.State) {
+	r.on = true
+	r.moves = r.moves[:0]
+	for _, entry := range state.History() {
+		r.moves = append(r.moves, entry.Move)
+	}
+	r.shown = len(r.moves)
+	if r.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.mu.Lock()
+	analysis := r.analysis
+	r.mu.Unlock()
+	go func(s *game.State) {
+		steps, err := review.Analyze(ctx, s, r.oracle, reviewSearchTime, func(done, total int) {
+			r.mu.Lock()
+			if r.analysis == analysis {
+				r.done, r.total = done, total
+			}
+			r.mu.Unlock()
+		})
+		r.mu.Lock()
+		if r.analysis == analysis {
+			r.steps, r.err = steps, err
+		}
+		r.mu.Unlock()
+	}(state.Clone())
+}
+
+// back takes back the move shown last, unless no move that can be
+// taken back is left.
+func (r *reviewer) back(state *game.State) bool {
+	if r.shown == 0 || state.Undo() != nil {
+		return false
+	}
+	r.shown--
+	return true
+}
+
+func (r *reviewer) forward(state *game.State) {
+	if r.shown < len(r.moves) && state.Apply(r.moves[r.shown]) == nil {
+		r.shown++
+	}
+}
+
+// stop cancels the analysis of the game reviewed, and forgets it.
+// The review must be over.
+func (r *reviewer) stop() {
+	if r.cancel != nil {
+		r.cancel()
+		r.cancel = nil
+	}
+	r.mu.Lock()
+	r.analysis++
+	r.done, r.total, r.steps, r.err = 0, 0, nil, nil
+	r.mu.Unlock()
+}
+
+// step returns the review of the move shown last, or false if it is
+// not known yet.
+func (r *reviewer) step() (review.Step, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// moves played before the analysis starts, such as handicap
+	// stones, are not reviewed
+	i := r.shown - 1 - (len(r.moves) - len(r.steps))
+	if r.steps == nil || i < 0 {
+		return review.Step{}, false
+	}
+	return r.steps[i], true
+}
+
+// render outlines the move shown last in the color of how it was
+// judged, and marks the best move instead.
+func (r *reviewer) render(context *imdraw.IMDraw, state *game.State) {
+	step, ok := r.step()
+	if !ok || !step.Reviewed {
+		return
+	}
+
+	if cell := state.Grid.At(step.Move.X, step.Move.Y); cell != nil {
+		cell.RenderRect(context, judgementColor(step.Judgement), reviewMoveWidth)
+	}
+	if step.Best != step.Move {
+		if cell := state.Grid.At(step.Best.X, step.Best.Y); cell != nil {
+			cell.RenderMark(context, bestHintColor)
+		}
+	}
+}
+
+// text returns the line describing the move shown last.
+func (r *reviewer) text(size int) string {
+	r.mu.Lock()
+	done, total, err := r.done, r.total, r.err
+	r.mu.Unlock()
+
+	switch {
+	case err != nil:
+		return fmt.Sprintf("Unable to analyze the game: %v", err)
+	case r.shown == 0:
+		return "Start of the game"
+	}
+	step, ok := r.step()
+	if !ok {
+		return fmt.Sprintf("Analyzing the game: %d/%d moves", done, total)
+	}
+
+	move := r.moves[r.shown-1]
+	if move.Choice != "" {
+		return fmt.Sprintf("%d. %s", r.shown, move.Choice)
+	}
+	name := commentary.Cell(move.X, move.Y, size)
+	if !step.Reviewed {
+		return fmt.Sprintf("%d. %s %s", r.shown, step.Kind, name)
+	}
+	line := fmt.Sprintf("%d. %s %s: %s -> %s", r.shown, step.Kind, name, step.Before, step.After)
+	if step.Judgement != review.Good {
+		line += fmt.Sprintf(", %s, best was %s", step.Judgement, commentary.Cell(step.Best.X, step.Best.Y, size))
+	}
+	return line
+}
+
+// promptText returns the keys used to step through the review.
+func (r *reviewer) promptText() string {
+	return fmt.Sprintf("REVIEW %d/%d: LEFT/RIGHT TO STEP, R TO RETURN", r.shown, len(r.moves))
+}
+
+func judgementColor(j review.Judgement) pixel.RGBA {
+	switch j {
+	case review.Inaccuracy:
+		return pixel.ToRGBA(inaccurateMoveColor)
+	case review.Blunder:
+		return pixel.ToRGBA(blunderColor)
+	}
+	return pixel.ToRGBA(goodMoveColor)
+}
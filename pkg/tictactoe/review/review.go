@@ -0,0 +1,273 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/ai"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/shape"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/solver"
+)
+
+const (
+	// inaccuracyDrop and blunderDrop are how much of its estimated
+	// win rate a move must give away to be an inaccuracy or a
+	// blunder, on boards too large to be solved.
+	inaccuracyDrop = 0.1
+	blunderDrop    = 0.3
+
+	// minVisits is the fewest playouts through a move for the win
+	// rate found for it to be trusted.
+	minVisits = 100
+)
+
+// Judgement grades a move against the best one available.
+type Judgement int
+
+const (
+	Good Judgement = iota
+	// Inaccuracy keeps the outcome of the game, but makes a win
+	// take longer or a loss come sooner, or gives away some of the
+	// estimated win rate.
+	Inaccuracy
+	// Blunder changes the outcome of the game with perfect play,
+	// or gives away much of the estimated win rate.
+	Blunder
+)
+
+func (j Judgement) String() string {
+	switch j {
+	case Inaccuracy:
+		return "inaccuracy"
+	case Blunder:
+		return "blunder"
+	}
+	return "good"
+}
+
+// Evaluation is how good a position is for one side. Positions
+// small enough to be solved have an exact value, and the number of
+// moves left until the game ends with perfect play. Others have the
+// share of playouts the side won in a tree search.
+type Evaluation struct {
+	Solved  bool
+	Value   solver.Value
+	Moves   int
+	WinRate float64
+}
+
+// String returns the evaluation as shown by hints, e.g. W3, D, L4 or
+// 55%.
+func (e Evaluation) String() string {
+	if !e.Solved {
+		return fmt.Sprintf("%.0f%%", e.WinRate*100)
+	}
+	switch e.Value {
+	case solver.Win:
+		return fmt.Sprintf("W%d", e.Moves)
+	case solver.Loss:
+		return fmt.Sprintf("L%d", e.Moves)
+	}
+	return "D"
+}
+
+// rank orders solved evaluations: wins before draws before losses,
+// quicker wins first and slower losses first.
+func (e Evaluation) rank(cells int) int {
+	switch e.Value {
+	case solver.Win:
+		return 2*cells - e.Moves
+	case solver.Loss:
+		return e.Moves - 2*cells
+	}
+	return 0
+}
+
+// Step is the review of one move of a game.
+type Step struct {
+	Move game.Move
+	Kind shape.ShapeKind
+	// Reviewed is false for moves that were not evaluated, such as
+	// the choices made during an opening.
+	Reviewed bool
+	// Before is the evaluation of the position for the side making
+	// the move, with the best move played, and After the evaluation
+	// once the move was made.
+	Before Evaluation
+	After  Evaluation
+	// Best is the best move available instead.
+	Best      game.Move
+	Judgement Judgement
+}
+
+// Analyze reviews every move of the game played in s. Positions
+// small enough to be solved are evaluated by the oracle, and others
+// by a tree search lasting think. Progress, if set, is called after
+// every move reviewed. The analysis stops with an error once ctx is
+// done.
+func Analyze(ctx context.Context, s *game.State, oracle *solver.Oracle, think time.Duration, progress func(done, total int)) ([]Step, error) {
+	history := s.History()
+	replay := s.Clone()
+	for replay.Undo() == nil {
+	}
+
+	start := len(replay.History())
+	steps := []Step{}
+	for i := start; i < len(history); i++ {
+		entry := history[i]
+		step := Step{Move: entry.Move, Kind: entry.Kind}
+		if entry.Choice == "" && replay.Phase() == game.PhasePlay {
+			if err := analyze(ctx, replay, oracle, think, &step); err != nil {
+				return nil, err
+			}
+		}
+		if err := replay.Apply(entry.Move); err != nil {
+			return nil, err
+		}
+
+		steps = append(steps, step)
+		if progress != nil {
+			progress(len(steps), len(history)-start)
+		}
+	}
+	return steps, nil
+}
+
+// analyze evaluates step, about to be made in s.
+func analyze(ctx context.Context, s *game.State, oracle *solver.Oracle, think time.Duration, step *Step) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if p, err := solver.FromState(s); err == nil && p.Size <= solver.MaxSize && solve(s, oracle, step) {
+		return nil
+	}
+	return search(ctx, s, think, step)
+}
+
+// solve evaluates step with the oracle, by evaluating every move
+// available in s. It returns false if the positions reached cannot
+// be solved.
+func solve(s *game.State, oracle *solver.Oracle, step *Step) bool {
+	for i, move := range s.Legal() {
+		e, err := evaluate(s, move, oracle)
+		if err != nil {
+			return false
+		}
+		if move == step.Move {
+			step.After = e
+		}
+		if i == 0 || e.rank(len(s.Grid)) > step.Before.rank(len(s.Grid)) {
+			step.Before, step.Best = e, move
+		}
+	}
+	if step.After == step.Before {
+		step.Best = step.Move
+	}
+
+	step.Reviewed = true
+	switch {
+	case step.After.Value < step.Before.Value:
+		step.Judgement = Blunder
+	case step.After.rank(len(s.Grid)) < step.Before.rank(len(s.Grid)):
+		step.Judgement = Inaccuracy
+	}
+	return true
+}
+
+// evaluate returns the evaluation of playing move in s for the side
+// making it.
+func evaluate(s *game.State, move game.Move, oracle *solver.Oracle) (Evaluation, error) {
+	after := s.Clone()
+	if err := after.Apply(move); err != nil {
+		return Evaluation{}, err
+	}
+	p, err := solver.FromState(after)
+	if err != nil {
+		return Evaluation{}, err
+	}
+	value, distance, err := oracle.Evaluate(p)
+	if err != nil {
+		return Evaluation{}, err
+	}
+
+	if p.Turn != s.Turn() {
+		// the value of the position reached is for the other side
+		value = -value
+	}
+	return Evaluation{Solved: true, Value: value, Moves: distance + 1}, nil
+}
+
+// search evaluates step by the win rates a tree search from s finds
+// for the move made and the best one.
+func search(ctx context.Context, s *game.State, think time.Duration, step *Step) error {
+	candidates := (&ai.MCTS{Duration: think}).Search(ctx, s)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if candidates[0].Visits < minVisits {
+		// too many moves are worth trying, e.g. on an empty board,
+		// for any to be told apart
+		return nil
+	}
+
+	step.Best = candidates[0].Move
+	step.Before = Evaluation{WinRate: candidates[0].WinRate()}
+	played := false
+	for _, c := range candidates {
+		if c.Move == step.Move && c.Visits >= minVisits {
+			step.After, played = Evaluation{WinRate: c.WinRate()}, true
+		}
+	}
+	if !played {
+		// the search did not try the move, so the position it
+		// reached is searched on its own
+		rate, err := winRate(ctx, s, step.Move, think)
+		if err != nil {
+			return err
+		}
+		step.After = Evaluation{WinRate: rate}
+	}
+	if step.After.WinRate >= step.Before.WinRate {
+		step.Before, step.Best = step.After, step.Move
+	}
+
+	step.Reviewed = true
+	switch drop := step.Before.WinRate - step.After.WinRate; {
+	case drop >= blunderDrop:
+		step.Judgement = Blunder
+	case drop >= inaccuracyDrop:
+		step.Judgement = Inaccuracy
+	}
+	return nil
+}
+
+// winRate returns the share of playouts won by the side making move
+// in s, in a tree search from the position it reaches.
+func winRate(ctx context.Context, s *game.State, move game.Move, think time.Duration) (float64, error) {
+	after := s.Clone()
+	if err := after.Apply(move); err != nil {
+		return 0, err
+	}
+	if result := after.Result(); result != nil {
+		switch result.Winner {
+		case "":
+			return 0.5, nil
+		case s.Turn():
+			return 1, nil
+		}
+		return 0, nil
+	}
+
+	best := (&ai.MCTS{Duration: think}).Search(ctx, after)[0].WinRate()
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if after.Turn() == s.Turn() {
+		return best, nil
+	}
+	return 1 - best, nil
+}
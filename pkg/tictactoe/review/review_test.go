@@ -0,0 +1,121 @@
+package review
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/grid"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/solver"
+)
+
+func TestAnalyze(t *testing.T) {
+	solved := func(value solver.Value, moves int) Evaluation {
+		return Evaluation{Solved: true, Value: value, Moves: moves}
+	}
+	type step struct {
+		move          game.Move
+		judgement     Judgement
+		before, after Evaluation
+		best          game.Move
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "blunders",
+			steps: []step{
+				{game.Move{X: 1, Y: 1}, Good, solved(solver.Draw, 9), solved(solver.Draw, 9), game.Move{X: 1, Y: 1}},
+				{game.Move{X: 1, Y: 0}, Blunder, solved(solver.Draw, 8), solved(solver.Loss, 6), game.Move{X: 0, Y: 0}},
+				{game.Move{X: 0, Y: 0}, Good, solved(solver.Win, 5), solved(solver.Win, 5), game.Move{X: 0, Y: 0}},
+				{game.Move{X: 2, Y: 2}, Good, solved(solver.Loss, 4), solved(solver.Loss, 4), game.Move{X: 2, Y: 2}},
+				{game.Move{X: 2, Y: 0}, Blunder, solved(solver.Win, 3), solved(solver.Draw, 5), game.Move{X: 0, Y: 1}},
+				{game.Move{X: 0, Y: 2}, Good, solved(solver.Draw, 4), solved(solver.Draw, 4), game.Move{X: 0, Y: 2}},
+				{game.Move{X: 0, Y: 1}, Blunder, solved(solver.Draw, 3), solved(solver.Loss, 2), game.Move{X: 1, Y: 2}},
+				{game.Move{X: 1, Y: 2}, Good, solved(solver.Win, 1), solved(solver.Win, 1), game.Move{X: 1, Y: 2}},
+			},
+		},
+		{
+			name: "inaccuracies",
+			steps: []step{
+				{game.Move{X: 0, Y: 0}, Good, solved(solver.Draw, 9), solved(solver.Draw, 9), game.Move{X: 0, Y: 0}},
+				{game.Move{X: 1, Y: 0}, Blunder, solved(solver.Draw, 8), solved(solver.Loss, 6), game.Move{X: 1, Y: 1}},
+				{game.Move{X: 0, Y: 1}, Good, solved(solver.Win, 5), solved(solver.Win, 5), game.Move{X: 0, Y: 1}},
+				{game.Move{X: 2, Y: 0}, Inaccuracy, solved(solver.Loss, 4), solved(solver.Loss, 2), game.Move{X: 0, Y: 2}},
+				{game.Move{X: 1, Y: 1}, Inaccuracy, solved(solver.Win, 1), solved(solver.Win, 3), game.Move{X: 0, Y: 2}},
+				{game.Move{X: 0, Y: 2}, Good, solved(solver.Loss, 2), solved(solver.Loss, 2), game.Move{X: 0, Y: 2}},
+				{game.Move{X: 2, Y: 2}, Good, solved(solver.Win, 1), solved(solver.Win, 1), game.Move{X: 2, Y: 2}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := game.NewState(grid.New(game.Standard.Size), game.Rules{Variant: game.Standard, Opening: game.NoOpening})
+			for _, step := range test.steps {
+				if err := s.Apply(step.move); err != nil {
+					t.Fatalf("move %v: %v", step.move, err)
+				}
+			}
+
+			steps, err := Analyze(context.Background(), s, solver.NewOracle(), time.Second, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(steps) != len(test.steps) {
+				t.Fatalf("expected %d steps, got %d", len(test.steps), len(steps))
+			}
+			for i, want := range test.steps {
+				got := steps[i]
+				if !got.Reviewed || got.Judgement != want.judgement || got.Before != want.before || got.After != want.after || got.Best != want.best {
+					t.Errorf("move %d %v: expected %s, %s -> %s, best %v, got %s, %s -> %s, best %v",
+						i+1, want.move, want.judgement, want.before, want.after, want.best, got.Judgement, got.Before, got.After, got.Best)
+				}
+			}
+		})
+	}
+}
+
+func TestWinRate(t *testing.T) {
+	// X has four in a row, open at both ends, and O is to move
+	s := game.NewState(grid.New(game.Gomoku.Size), game.Rules{Variant: game.Gomoku, Opening: game.NoOpening})
+	for _, m := range []game.Move{{X: 3, Y: 7}, {X: 0, Y: 0}, {X: 4, Y: 7}, {X: 0, Y: 2}, {X: 5, Y: 7}, {X: 0, Y: 4}, {X: 6, Y: 7}} {
+		if err := s.Apply(m); err != nil {
+			t.Fatalf("move %v: %v", m, err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		s        *game.State
+		move     game.Move
+		min, max float64
+	}{
+		{name: "blocking one end", s: s, move: game.Move{X: 2, Y: 7}, max: 0.1},
+		{name: "winning", s: clone(t, s, game.Move{X: 2, Y: 7}), move: game.Move{X: 7, Y: 7}, min: 1, max: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rate, err := winRate(context.Background(), test.s, test.move, 200*time.Millisecond)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rate < test.min || rate > test.max {
+				t.Errorf("expected a win rate between %v and %v, got %v", test.min, test.max, rate)
+			}
+		})
+	}
+}
+
+func clone(t *testing.T, s *game.State, moves ...game.Move) *game.State {
+	t.Helper()
+	s = s.Clone()
+	for _, m := range moves {
+		if err := s.Apply(m); err != nil {
+			t.Fatalf("move %v: %v", m, err)
+		}
+	}
+	return s
+}
@@ -33,6 +33,14 @@ func (s *Shape) String() string {
 	return string(s.kind)
 }
 
+// Faded returns a copy of the shape drawn with the given
+// opacity, between 0 and 1.
+func (s *Shape) Faded(alpha float64) *Shape {
+	faded := *s
+	faded.color = pixel.ToRGBA(s.color).Mul(pixel.Alpha(alpha))
+	return &faded
+}
+
 func (s *Shape) Render(context *imdraw.IMDraw) {
 	context.Color = s.color
 	if s.kind == CrossShape {
@@ -88,6 +96,12 @@ type ShapeDecider struct {
 	next ShapeKind
 }
 
+// Peek returns the shape that the next call to Next will
+// return, without advancing the decider.
+func (n *ShapeDecider) Peek() ShapeKind {
+	return n.next
+}
+
 func (n *ShapeDecider) Next() ShapeKind {
 	next := n.next
 	if n.next == CrossShape {
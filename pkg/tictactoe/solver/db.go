@@ -0,0 +1,306 @@
+package solver
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"sort"
+)
+
+const (
+	// dbMagic starts every database file.
+	dbMagic = "TTDB"
+
+	// dbVersion is the version of the database format. It must
+	// change whenever the layout of the file, or the way positions
+	// are hashed, changes, so files written before are rejected.
+	dbVersion = 1
+)
+
+var (
+	ErrBadDB       = errors.New("not a position database")
+	ErrStaleDB     = errors.New("position database was written by an incompatible version")
+	ErrCorruptDB   = errors.New("position database is corrupt")
+	ErrUnreachable = errors.New("position is not in the database")
+)
+
+// dbHeader is written at the start of a database file, and is
+// followed by Count records and the CRC-32 checksum of everything
+// before it.
+type dbHeader struct {
+	Magic   [4]byte
+	Version uint16
+	Size    uint8
+	Length  uint8
+	Count   uint32
+}
+
+// Record is the solution of a position in a database.
+type Record struct {
+	// Key is the hash of the position, shared by every position
+	// equal to it under a symmetry of the board.
+	Key   uint64
+	Value Value
+	// Distance is the number of moves left until the game ends when
+	// both sides play perfectly.
+	Distance uint8
+}
+
+// DB holds the solution of every position reachable on a board,
+// sorted by key.
+type DB struct {
+	solver  *Solver
+	records []Record
+}
+
+// Size returns the size of the board the database is for.
+func (d *DB) Size() int {
+	return d.solver.size
+}
+
+// Length returns the number of shapes in a row needed to win on
+// the board the database is for.
+func (d *DB) Length() int {
+	return d.solver.length
+}
+
+// Len returns the number of positions in the database.
+func (d *DB) Len() int {
+	return len(d.records)
+}
+
+// Lookup returns the value of p for the side to move and the number
+// of moves left until the game ends.
+func (d *DB) Lookup(p Position) (Value, int, error) {
+	b, err := d.solver.board(p)
+	if err != nil {
+		return Draw, 0, err
+	}
+
+	key := d.solver.canonical(b)
+	i := sort.Search(len(d.records), func(i int) bool {
+		return d.records[i].Key >= key
+	})
+	if i == len(d.records) || d.records[i].Key != key {
+		return Draw, 0, ErrUnreachable
+	}
+	return d.records[i].Value, int(d.records[i].Distance), nil
+}
+
+// Best returns the value of p for the side to move, along with the
+// index of every cell the side to move can reach that value by
+// playing on, as Solver.Best does but without searching.
+func (d *DB) Best(p Position) (Value, []int, error) {
+	value, distance, err := d.Lookup(p)
+	if err != nil || distance == 0 {
+		return value, []int{}, err
+	}
+
+	moves, best := []int{}, 0
+	for _, i := range d.solver.order {
+		if p.Cells[i] != "" {
+			continue
+		}
+
+		child := p.Play(i)
+		v, distance, err := d.Lookup(child)
+		if err != nil {
+			return Draw, nil, err
+		}
+		if -v != value {
+			continue
+		}
+
+		// win as soon as possible, and lose as late as possible
+		rank := distance
+		if value == Loss {
+			rank = -distance
+		}
+		if len(moves) == 0 || rank < best {
+			moves, best = []int{}, rank
+		}
+		if rank == best {
+			moves = append(moves, i)
+		}
+	}
+	return value, moves, nil
+}
+
+// Write writes the database in its binary format.
+func (d *DB) Write(w io.Writer) error {
+	var buf bytes.Buffer
+	header := dbHeader{
+		Version: dbVersion,
+		Size:    uint8(d.solver.size),
+		Length:  uint8(d.solver.length),
+		Count:   uint32(len(d.records)),
+	}
+	copy(header.Magic[:], dbMagic)
+
+	binary.Write(&buf, binary.BigEndian, header)
+	for _, r := range d.records {
+		binary.Write(&buf, binary.BigEndian, r)
+	}
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(buf.Bytes()))
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Save writes the database to the file at path.
+func (d *DB) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if err := d.Write(w); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadDB reads a database written by Write, rejecting databases of
+// another version and any whose checksum does not match.
+func ReadDB(r io.Reader) (*DB, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	header := dbHeader{}
+	if err := binary.Read(bytes.NewReader(data), binary.BigEndian, &header); err != nil || string(header.Magic[:]) != dbMagic {
+		return nil, ErrBadDB
+	}
+	if header.Version != dbVersion {
+		return nil, fmt.Errorf("%w: version %d, expected %d", ErrStaleDB, header.Version, dbVersion)
+	}
+
+	recordSize := binary.Size(Record{})
+	body := binary.Size(header) + int(header.Count)*recordSize
+	if len(data) != body+4 {
+		return nil, fmt.Errorf("%w: expected %d bytes, found %d", ErrCorruptDB, body+4, len(data))
+	}
+	if crc32.ChecksumIEEE(data[:body]) != binary.BigEndian.Uint32(data[body:]) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrCorruptDB)
+	}
+
+	d := &DB{
+		solver:  New(int(header.Size), int(header.Length)),
+		records: make([]Record, header.Count),
+	}
+	if err := binary.Read(bytes.NewReader(data[binary.Size(header):body]), binary.BigEndian, d.records); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptDB, err)
+	}
+	return d, nil
+}
+
+// LoadDB reads the database in the file at path.
+func LoadDB(path string) (*DB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	d, err := ReadDB(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return d, nil
+}
+
+// Generate solves every position reachable from the empty board,
+// including finished ones, and returns them as a database.
+func (s *Solver) Generate() *DB {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := &board{cells: make([]int8, s.size*s.size), turn: 1, empties: s.size * s.size}
+	solved := map[uint64]Record{}
+	s.exact(b, solved)
+
+	d := &DB{solver: New(s.size, s.length)}
+	for _, r := range solved {
+		d.records = append(d.records, r)
+	}
+	sort.Slice(d.records, func(i, j int) bool {
+		return d.records[i].Key < d.records[j].Key
+	})
+	return d
+}
+
+// exact returns the score of b for the side to move, as search
+// does, recording the solution of b and of every position reachable
+// from it. Nothing is pruned, so every score found is exact.
+func (s *Solver) exact(b *board, solved map[uint64]Record) int8 {
+	key := s.canonical(b)
+	if r, ok := solved[key]; ok {
+		return scoreOf(r, b.empties)
+	}
+	s.nodes++
+
+	best := int8(0)
+	if b.empties > 0 {
+		best = math.MinInt8 + 1
+		for _, i := range s.order {
+			if b.cells[i] != 0 {
+				continue
+			}
+
+			score := int8(b.empties)
+			s.play(b, i)
+			if s.wins(b, i) {
+				// the side to move has lost
+				solved[s.canonical(b)] = Record{Key: s.canonical(b), Value: Loss}
+			} else {
+				score = -s.exact(b, solved)
+			}
+			s.undo(b, i)
+
+			if score > best {
+				best = score
+			}
+		}
+	}
+
+	value, distance := solution(best, b.empties)
+	solved[key] = Record{Key: key, Value: value, Distance: uint8(distance)}
+	return best
+}
+
+// solution returns the value and the number of moves left until
+// the game ends of a position with empties empty cells and the
+// given score.
+func solution(score int8, empties int) (Value, int) {
+	switch {
+	case score > 0:
+		return Win, empties - int(score) + 1
+	case score < 0:
+		return Loss, empties + int(score) + 1
+	}
+	return Draw, empties
+}
+
+// scoreOf returns the score of a solved position with empties empty
+// cells, as search would find it.
+func scoreOf(r Record, empties int) int8 {
+	switch r.Value {
+	case Win:
+		return int8(empties - int(r.Distance) + 1)
+	case Loss:
+		return -int8(empties - int(r.Distance) + 1)
+	}
+	return 0
+}
@@ -0,0 +1,59 @@
+package solver
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func TestReadDB(t *testing.T) {
+	var buf bytes.Buffer
+	if err := New(3, 3).Generate().Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	valid := buf.Bytes()
+
+	tests := []struct {
+		name   string
+		change func(data []byte) []byte
+		err    error
+	}{
+		{name: "valid", change: func(data []byte) []byte { return data }},
+		{name: "empty", change: func(data []byte) []byte { return nil }, err: ErrBadDB},
+		{name: "bad magic", change: func(data []byte) []byte {
+			data[0] = 'X'
+			return data
+		}, err: ErrBadDB},
+		{name: "stale version", change: func(data []byte) []byte {
+			binary.BigEndian.PutUint16(data[4:], dbVersion+1)
+			return data
+		}, err: ErrStaleDB},
+		{name: "truncated", change: func(data []byte) []byte { return data[:len(data)-1] }, err: ErrCorruptDB},
+		{name: "checksum mismatch", change: func(data []byte) []byte {
+			data[len(data)/2] ^= 0xff
+			return data
+		}, err: ErrCorruptDB},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := test.change(append([]byte(nil), valid...))
+			d, err := ReadDB(bytes.NewReader(data))
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected error %v, got %v", test.err, err)
+			}
+			if err != nil {
+				return
+			}
+
+			p, err := ParsePosition(".../.../...", 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if value, distance, err := d.Lookup(p); err != nil || value != Draw || distance != 9 {
+				t.Errorf("expected a draw in 9 moves, got a %s in %d moves (%v)", value, distance, err)
+			}
+		})
+	}
+}
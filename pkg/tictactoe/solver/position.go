@@ -0,0 +1,129 @@
+package solver
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/shape"
+)
+
+// ErrUnsupported is returned for games the solver cannot solve.
+var ErrUnsupported = errors.New("position cannot be solved")
+
+// Position is a board of the standard game, along with the number
+// of shapes in a row needed to win and the kind of shape to be
+// placed next.
+type Position struct {
+	Size   int
+	Length int
+	// Cells holds the kind of shape on each cell, row by row, or an
+	// empty kind for empty cells.
+	Cells []shape.ShapeKind
+	Turn  shape.ShapeKind
+}
+
+// ParsePosition parses a board written row by row, with rows
+// separated by slashes, e.g. "x.o/.x./...". Cells are either x, o
+// or a dot for an empty cell. X is taken to move first, so the
+// kind to move follows from the number of shapes of each kind. A
+// length of zero means a whole row is needed to win.
+func ParsePosition(position string, length int) (Position, error) {
+	rows := strings.Split(strings.ToUpper(position), "/")
+	p := Position{Size: len(rows), Length: length}
+	if p.Length == 0 {
+		p.Length = p.Size
+	}
+
+	crosses, circles := 0, 0
+	for _, row := range rows {
+		if len(row) != p.Size {
+			return Position{}, fmt.Errorf("invalid position %q: expected %d rows of %d cells", position, p.Size, p.Size)
+		}
+		for _, c := range row {
+			switch kind := shape.ShapeKind(c); kind {
+			case shape.CrossShape:
+				crosses++
+				p.Cells = append(p.Cells, kind)
+			case shape.CircleShape:
+				circles++
+				p.Cells = append(p.Cells, kind)
+			case ".":
+				p.Cells = append(p.Cells, "")
+			default:
+				return Position{}, fmt.Errorf("invalid position %q: unknown cell %q", position, c)
+			}
+		}
+	}
+
+	switch crosses - circles {
+	case 0:
+		p.Turn = shape.CrossShape
+	case 1:
+		p.Turn = shape.CircleShape
+	default:
+		return Position{}, fmt.Errorf("invalid position %q: %d crosses and %d circles cannot follow from alternating moves", position, crosses, circles)
+	}
+	if p.Length < 1 || p.Length > p.Size {
+		return Position{}, fmt.Errorf("invalid position %q: cannot need %d in a row on a board of size %d", position, p.Length, p.Size)
+	}
+	return p, nil
+}
+
+// FromState returns the position reached in s. Only games of the
+// standard rules, played on boards of any size, can be solved:
+// captures, Renju restrictions and handicaps changing the length
+// needed to win are not supported, and any opening must be over.
+func FromState(s *game.State) (Position, error) {
+	first := s.WinLength(shape.CrossShape)
+	switch {
+	case s.Rules.Variant.Captures, s.Rules.Variant.Renju:
+		return Position{}, fmt.Errorf("%w: variant %q is not supported", ErrUnsupported, s.Rules.Variant.Name)
+	case first != s.WinLength(shape.CircleShape):
+		return Position{}, fmt.Errorf("%w: both sides must need the same number in a row", ErrUnsupported)
+	case s.Phase() == game.PhaseOpening || s.Phase() == game.PhaseChoice:
+		return Position{}, fmt.Errorf("%w: the opening is not over", ErrUnsupported)
+	}
+
+	p := Position{Size: s.Grid.Size(), Length: first, Turn: s.Turn()}
+	for _, cell := range s.Grid {
+		p.Cells = append(p.Cells, cell.Kind())
+	}
+	return p, nil
+}
+
+// String returns the position as accepted by ParsePosition.
+func (p Position) String() string {
+	rows := []string{}
+	for y := 0; y < p.Size; y++ {
+		row := ""
+		for _, kind := range p.Cells[y*p.Size : (y+1)*p.Size] {
+			if kind == "" {
+				row += "."
+				continue
+			}
+			row += strings.ToLower(string(kind))
+		}
+		rows = append(rows, row)
+	}
+	return strings.Join(rows, "/")
+}
+
+// Move returns the game move placing a shape on the cell at index.
+func (p Position) Move(index int) game.Move {
+	return game.Move{X: index % p.Size, Y: index / p.Size}
+}
+
+// Play returns the position reached by placing the next shape on
+// the cell at index.
+func (p Position) Play(index int) Position {
+	next := p
+	next.Cells = append([]shape.ShapeKind{}, p.Cells...)
+	next.Cells[index] = p.Turn
+	next.Turn = shape.CrossShape
+	if p.Turn == shape.CrossShape {
+		next.Turn = shape.CircleShape
+	}
+	return next
+}
@@ -0,0 +1,461 @@
+package solver
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/grid"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/shape"
+)
+
+const (
+	// MaxSize is the largest board an Oracle solves. Larger boards
+	// take too long to solve while a game is being played.
+	MaxSize = 4
+
+	// zobristSeed seeds the keys positions are hashed with, so the
+	// same position always hashes the same.
+	zobristSeed = 0x7ac70e
+)
+
+// Value is the outcome of a position for the side to move when
+// both sides play perfectly.
+type Value int8
+
+const (
+	Loss Value = -1
+	Draw Value = 0
+	Win  Value = 1
+)
+
+func (v Value) String() string {
+	switch v {
+	case Loss:
+		return "loss"
+	case Win:
+		return "win"
+	}
+	return "draw"
+}
+
+// bound tells how the score of a table entry relates to the actual
+// score of its position, as searches cut short by alpha-beta
+// pruning only find a bound on the score.
+type bound uint8
+
+const (
+	exact bound = iota
+	lower
+	upper
+)
+
+type entry struct {
+	score int8
+	bound bound
+}
+
+// Solver computes the value of positions on a board of a given size
+// and length needed to win. Solved positions are kept in a table
+// shared by every position of the same board, where positions equal
+// under any of the 8 symmetries of the board share a single entry.
+type Solver struct {
+	size   int
+	length int
+
+	// keys holds the Zobrist key of each kind of shape on each cell,
+	// and turn the key of circles being next to move.
+	keys [][2]uint64
+	turn uint64
+	// symmetries maps every cell to the cell it lands on under each
+	// symmetry of the board.
+	symmetries [8][]int
+	// lines lists the lines of cells through each cell that a win
+	// can be made on.
+	lines [][][]int
+	// order lists every cell, closest to the center first.
+	order []int
+
+	mu    sync.Mutex
+	table map[uint64]entry
+	nodes int
+}
+
+// board is a position being searched, along with its hash under
+// each symmetry.
+type board struct {
+	cells   []int8
+	turn    int8
+	empties int
+	hashes  [8]uint64
+}
+
+// Solve returns the value of p for the side to move.
+func (s *Solver) Solve(p Position) (Value, error) {
+	value, _, err := s.Evaluate(p)
+	return value, err
+}
+
+// Evaluate returns the value of p for the side to move, and the
+// number of moves left until the game ends.
+func (s *Solver) Evaluate(p Position) (Value, int, error) {
+	b, err := s.board(p)
+	if err != nil {
+		return Draw, 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.over(b) {
+		return Loss, 0, nil
+	}
+	value, distance := solution(s.search(b, math.MinInt8+1, math.MaxInt8), b.empties)
+	return value, distance, nil
+}
+
+// Best returns the value of p for the side to move, along with the
+// index of every cell the side to move can reach that value by
+// playing on. Moves winning the soonest, or losing the latest, are
+// the only ones returned.
+func (s *Solver) Best(p Position) (Value, []int, error) {
+	b, err := s.board(p)
+	if err != nil {
+		return Draw, nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.over(b) {
+		return Loss, []int{}, nil
+	}
+	if b.empties == 0 {
+		return Draw, []int{}, nil
+	}
+
+	best, moves := int8(math.MinInt8), []int{}
+	for _, i := range s.order {
+		if b.cells[i] != 0 {
+			continue
+		}
+
+		score := s.child(b, i, math.MinInt8+1, math.MaxInt8)
+		if score > best {
+			best, moves = score, []int{}
+		}
+		if score == best {
+			moves = append(moves, i)
+		}
+	}
+
+	switch {
+	case best > 0:
+		return Win, moves, nil
+	case best < 0:
+		return Loss, moves, nil
+	}
+	return Draw, moves, nil
+}
+
+// over returns true if the game was won by the previous move.
+func (s *Solver) over(b *board) bool {
+	for i, c := range b.cells {
+		if c != 0 && s.wins(b, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// Nodes returns the number of positions searched so far.
+func (s *Solver) Nodes() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.nodes
+}
+
+// child plays on cell i of b and returns the score of the result
+// for the side that played. A win scores the number of empty cells
+// left before it was made, so sooner wins score higher.
+func (s *Solver) child(b *board, i int, alpha, beta int8) int8 {
+	score := int8(b.empties)
+	s.play(b, i)
+	if !s.wins(b, i) {
+		score = -s.search(b, -beta, -alpha)
+	}
+	s.undo(b, i)
+	return score
+}
+
+// search returns the score of b for the side to move, using negamax
+// with alpha-beta pruning and the table of solved positions.
+func (s *Solver) search(b *board, alpha, beta int8) int8 {
+	s.nodes++
+	if b.empties == 0 {
+		return 0
+	}
+
+	initial := alpha
+	key := s.canonical(b)
+	if e, ok := s.table[key]; ok {
+		switch {
+		case e.bound == exact:
+			return e.score
+		case e.bound == lower && e.score > alpha:
+			alpha = e.score
+		case e.bound == upper && e.score < beta:
+			beta = e.score
+		}
+		if alpha >= beta {
+			return e.score
+		}
+	}
+
+	best := int8(math.MinInt8 + 1)
+	for _, i := range s.order {
+		if b.cells[i] != 0 {
+			continue
+		}
+
+		if score := s.child(b, i, alpha, beta); score > best {
+			best = score
+		}
+		if best > alpha {
+			alpha = best
+		}
+		if alpha >= beta {
+			break
+		}
+	}
+
+	e := entry{score: best}
+	switch {
+	case best <= initial:
+		e.bound = upper
+	case best >= beta:
+		e.bound = lower
+	}
+	s.table[key] = e
+	return best
+}
+
+func (s *Solver) play(b *board, i int) {
+	b.cells[i] = b.turn
+	for t, sym := range s.symmetries {
+		b.hashes[t] ^= s.keys[sym[i]][b.turn-1] ^ s.turn
+	}
+	b.turn = 3 - b.turn
+	b.empties--
+}
+
+func (s *Solver) undo(b *board, i int) {
+	b.turn = 3 - b.turn
+	for t, sym := range s.symmetries {
+		b.hashes[t] ^= s.keys[sym[i]][b.turn-1] ^ s.turn
+	}
+	b.cells[i] = 0
+	b.empties++
+}
+
+// wins returns true if the shape on cell i of b is part of a win.
+func (s *Solver) wins(b *board, i int) bool {
+	for _, line := range s.lines[i] {
+		won := true
+		for _, c := range line {
+			if b.cells[c] != b.cells[i] {
+				won = false
+				break
+			}
+		}
+		if won {
+			return true
+		}
+	}
+	return false
+}
+
+// canonical returns the hash shared by b and every position equal
+// to it under a symmetry of the board.
+func (s *Solver) canonical(b *board) uint64 {
+	key := b.hashes[0]
+	for _, h := range b.hashes[1:] {
+		if h < key {
+			key = h
+		}
+	}
+	return key
+}
+
+// board returns p as a board to search.
+func (s *Solver) board(p Position) (*board, error) {
+	if p.Size != s.size || p.Length != s.length {
+		return nil, fmt.Errorf("%w: solver is for boards of size %d needing %d in a row, not size %d needing %d", ErrUnsupported, s.size, s.length, p.Size, p.Length)
+	}
+
+	b := &board{cells: make([]int8, len(p.Cells)), turn: 1}
+	if p.Turn == shape.CircleShape {
+		b.turn = 2
+		for t := range b.hashes {
+			b.hashes[t] ^= s.turn
+		}
+	}
+	for i, kind := range p.Cells {
+		switch kind {
+		case shape.CrossShape:
+			b.cells[i] = 1
+		case shape.CircleShape:
+			b.cells[i] = 2
+		default:
+			b.empties++
+			continue
+		}
+		for t, sym := range s.symmetries {
+			b.hashes[t] ^= s.keys[sym[i]][b.cells[i]-1]
+		}
+	}
+	return b, nil
+}
+
+func New(size, length int) *Solver {
+	s := &Solver{
+		size:       size,
+		length:     length,
+		symmetries: grid.Symmetries(size),
+		table:      make(map[uint64]entry),
+	}
+
+	r := rand.New(rand.NewSource(zobristSeed))
+	s.turn = r.Uint64()
+	for i := 0; i < size*size; i++ {
+		s.keys = append(s.keys, [2]uint64{r.Uint64(), r.Uint64()})
+	}
+
+	s.lines = make([][][]int, size*size)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			for _, d := range [][2]int{{1, 0}, {0, 1}, {1, 1}, {-1, 1}} {
+				line := []int{}
+				for n := 0; n < length; n++ {
+					cx, cy := x+d[0]*n, y+d[1]*n
+					if cx < 0 || cy < 0 || cx >= size || cy >= size {
+						break
+					}
+					line = append(line, cy*size+cx)
+				}
+				if len(line) < length {
+					continue
+				}
+				for _, c := range line {
+					s.lines[c] = append(s.lines[c], line)
+				}
+			}
+		}
+	}
+
+	center := float64(size-1) / 2
+	distance := func(i int) float64 {
+		return math.Abs(float64(i%size)-center) + math.Abs(float64(i/size)-center)
+	}
+	for i := 0; i < size*size; i++ {
+		s.order = append(s.order, i)
+	}
+	for i := 1; i < len(s.order); i++ {
+		for j := i; j > 0 && distance(s.order[j]) < distance(s.order[j-1]); j-- {
+			s.order[j], s.order[j-1] = s.order[j-1], s.order[j]
+		}
+	}
+	return s
+}
+
+// Oracle plays perfectly on boards small enough to be solved,
+// looking positions up in its databases when it can. It keeps a
+// solver for every size of board and length needed to win it is
+// asked about, so positions solved in one game are reused in the
+// next.
+type Oracle struct {
+	dbs []*DB
+
+	mu      sync.Mutex
+	solvers map[[2]int]*Solver
+}
+
+// Moves returns the best moves for the side to move in s, or false
+// if s cannot be solved.
+func (o *Oracle) Moves(s *game.State) ([]game.Move, bool) {
+	p, err := FromState(s)
+	if err != nil {
+		return nil, false
+	}
+
+	best, err := o.best(p)
+	if err != nil || len(best) == 0 {
+		return nil, false
+	}
+
+	moves := []game.Move{}
+	for _, i := range best {
+		moves = append(moves, p.Move(i))
+	}
+	return moves, true
+}
+
+// Evaluate returns the value of p for the side to move, and the
+// number of moves left until the game ends.
+func (o *Oracle) Evaluate(p Position) (Value, int, error) {
+	for _, d := range o.dbs {
+		if d.Size() == p.Size && d.Length() == p.Length {
+			if value, distance, err := d.Lookup(p); err == nil {
+				return value, distance, nil
+			}
+		}
+	}
+
+	solver, err := o.solver(p)
+	if err != nil {
+		return Draw, 0, err
+	}
+	return solver.Evaluate(p)
+}
+
+func (o *Oracle) best(p Position) ([]int, error) {
+	for _, d := range o.dbs {
+		if d.Size() == p.Size && d.Length() == p.Length {
+			if _, best, err := d.Best(p); err == nil {
+				return best, nil
+			}
+		}
+	}
+
+	solver, err := o.solver(p)
+	if err != nil {
+		return nil, err
+	}
+	_, best, err := solver.Best(p)
+	return best, err
+}
+
+// solver returns the solver for the board of p.
+func (o *Oracle) solver(p Position) (*Solver, error) {
+	if p.Size > MaxSize {
+		return nil, fmt.Errorf("%w: boards larger than %d are too large", ErrUnsupported, MaxSize)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	solver, ok := o.solvers[[2]int{p.Size, p.Length}]
+	if !ok {
+		solver = New(p.Size, p.Length)
+		o.solvers[[2]int{p.Size, p.Length}] = solver
+	}
+	return solver, nil
+}
+
+// NewOracle returns an oracle looking positions up in the given
+// databases before solving them.
+func NewOracle(dbs ...*DB) *Oracle {
+	return &Oracle{dbs: dbs, solvers: make(map[[2]int]*Solver)}
+}
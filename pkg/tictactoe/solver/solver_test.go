@@ -0,0 +1,49 @@
+package solver
+
+import "testing"
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name     string
+		position string
+		length   int
+		value    Value
+		distance int
+	}{
+		{name: "empty board", position: ".../.../...", value: Draw, distance: 9},
+		{name: "win in one", position: "xx./oo./...", value: Win, distance: 1},
+		{name: "fork against", position: "xx./.o./x.o", value: Loss, distance: 2},
+		{name: "game over", position: "xxx/oo./...", value: Loss, distance: 0},
+		{name: "opposite corners", position: "x../.o./..x", value: Draw, distance: 6},
+		{name: "three in a row on 4x4", position: "..../..../..../....", length: 3, value: Win, distance: 5},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := ParsePosition(test.position, test.length)
+			if err != nil {
+				t.Fatal(err)
+			}
+			value, distance, err := New(p.Size, p.Length).Evaluate(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if value != test.value {
+				t.Errorf("expected a %s, got a %s", test.value, value)
+			}
+			if distance != test.distance {
+				t.Errorf("expected the game to end in %d moves, got %d", test.distance, distance)
+			}
+		})
+	}
+}
+
+func TestEvaluateUnsupported(t *testing.T) {
+	p, err := ParsePosition("..../..../..../....", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := New(3, 3).Evaluate(p); err == nil {
+		t.Error("expected an error solving a board of another size")
+	}
+}
@@ -2,7 +2,9 @@ package tictactoe
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"golang.org/x/image/colornames"
 	"golang.org/x/image/font/basicfont"
@@ -12,29 +14,111 @@ import (
 	"github.com/faiface/pixel/pixelgl"
 	"github.com/faiface/pixel/text"
 
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/ai"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/book"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/commentary"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
 	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/grid"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/habits"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/player"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/record"
 	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/score"
-	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/shape"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/solver"
 )
 
 const (
 	winWidth  = 800
 	winHeight = 600
 
-	cellMargin  = 60
-	shapeMargin = 25
+	cellMargin = 60
 
-	winTextSize   = 4
-	scoreTextSize = 2
+	winTextSize    = 4
+	scoreTextSize  = 2
+	promptTextSize = 2
+	// commentTextSize is smaller than the prompt, as commentary on
+	// a move can run across most of the window.
+	commentTextSize = 1.5
 
 	scoreMarginX = 10
 	scoreMarginY = 5
+	// commentMarginY is the height of the commentary line, above
+	// the prompt.
+	commentMarginY = 35
 )
 
+// undoKey takes back the last move.
+var undoKey = pixelgl.KeyU
+
 var winBgcolor = colornames.Darkslategrey
+var forbiddenColor = colornames.Crimson
 var winTextAtlas = text.NewAtlas(basicfont.Face7x13, text.ASCII)
 
-func NewGame() {
+// Options configures a new game window.
+type Options struct {
+	Variant  *game.Variant
+	Opening  game.Opening
+	Match    game.MatchFormat
+	Handicap game.Handicap
+	Teams    bool
+	// Players describes what controls each side of the game, as
+	// accepted by newPlayer.
+	Players [2]string
+	// Seed is the seed of the mistakes made by computer players.
+	Seed int64
+	// SaveDir is the directory finished games are saved to, or
+	// empty if games should not be saved.
+	SaveDir string
+	// DBs are the position databases computer players look
+	// positions up in.
+	DBs []*solver.DB
+	// Weights, if set, are the weights computer players evaluate
+	// positions with.
+	Weights *ai.Weights
+	// Book, if set, holds the moves computer players play in the
+	// opening instead of searching.
+	Book *book.Book
+	// Profile is the name under which the habits of the human
+	// players are learned by adaptive computer players, and their
+	// saved games are recorded, and ProfileDir the directory the
+	// habits of every profile are kept in.
+	Profile    string
+	ProfileDir string
+	// Commentary shows a line of commentary on every move beneath
+	// the board, and writes it to saved games.
+	Commentary bool
+}
+
+func NewGame(opts Options) {
+	oracle := solver.NewOracle(opts.DBs...)
+	rules := game.Rules{
+		Variant:  opts.Variant,
+		Opening:  opts.Opening,
+		Handicap: opts.Handicap,
+		Teams:    opts.Teams,
+	}
+	c := computer{rules: rules, oracle: oracle, weights: opts.Weights, openings: opts.Book}
+	if opts.Profile != "" {
+		path, err := habits.Path(opts.ProfileDir, opts.Profile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		c.habits = path
+	}
+	players := []player.Player{}
+	inputs := []input{}
+	for i, spec := range opts.Players {
+		p, in, err := newPlayer(spec, opts.Seed+int64(i), c)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		players = append(players, p)
+		if in != nil {
+			inputs = append(inputs, in)
+		}
+	}
+
 	config := pixelgl.WindowConfig{
 		Title:  "Tic Tac Toe",
 		Bounds: pixel.R(0, 0, winWidth, winHeight),
@@ -48,13 +132,32 @@ func NewGame() {
 	}
 	window.Clear(winBgcolor)
 
-	gameWin := false
 	scoreKeeper := score.ScoreKeeper(make(map[string]int))
-	shapeDecider := shape.NewShapeDecider(shape.CrossShape)
 	bounds := window.Bounds()
 	context := imdraw.New(nil)
 	winTextContext := text.New(pixel.V(bounds.Max.X/2, bounds.Max.Y/2), winTextAtlas)
 	scoreTextContext := text.New(pixel.V(bounds.Min.X, bounds.Max.Y), winTextAtlas)
+	promptTextContext := text.New(pixel.V(bounds.Min.X+scoreMarginX, bounds.Min.Y+scoreMarginY), winTextAtlas)
+	hintTextContext := text.New(pixel.ZV, winTextAtlas)
+	commentTextContext := text.New(pixel.V(bounds.Max.X/2, bounds.Min.Y+commentMarginY), winTextAtlas)
+
+	g := grid.NewGrid(pixel.V(0, 0), bounds.Max.X, bounds.Max.Y, float64(opts.Variant.Size), cellMargin)
+	state := game.NewState(g, rules)
+	match := game.NewMatch(opts.Match)
+
+	profiles := map[string]string{}
+	for i, p := range players {
+		if _, ok := p.(*player.Human); ok && opts.Profile != "" {
+			profiles[state.Sides()[i].Name] = opts.Profile
+		}
+	}
+
+	// comments holds the commentary on every move of the history of
+	// the game.
+	comments := []string{}
+	var pending *turn
+	overlay := &hints{oracle: oracle, results: make(chan hintResult, 1)}
+	rev := &reviewer{oracle: oracle}
 
 	scoreRenderer := score.NewScoreRenderer()
 	scoreRenderer.RenderFunc(func(ctx *text.Text, scores score.ScoreKeeper) {
@@ -64,49 +167,312 @@ func NewGame() {
 		ctx.Dot.X += scoreMarginX
 		ctx.Dot.Y -= scoreMarginY
 
-		text := fmt.Sprintf("%s: %d", shape.CrossShape, scores.Get(string(shape.CrossShape)))
+		text := scoreText(state, scores, 0)
 		ctx.Dot.Y -= ctx.BoundsOf(text).H()
 		fmt.Fprintf(ctx, "%s\n", text)
 
-		text = fmt.Sprintf("%s: %d", shape.CircleShape, scores.Get(string(shape.CircleShape)))
+		text = scoreText(state, scores, 1)
 		ctx.Dot.X = bounds.Max.X/2 - ctx.BoundsOf(text).W() - scoreMarginX
 		fmt.Fprintf(ctx, "%s\n", text)
 	})
-
-	g := grid.NewGrid(pixel.V(0, 0), bounds.Max.X, bounds.Max.Y, grid.MaxCells, cellMargin)
+	if match.Format != game.SingleGame {
+		scoreRenderer.RenderFunc(func(ctx *text.Text, scores score.ScoreKeeper) {
+			text := standingText(match)
+			ctx.Dot.Y -= ctx.BoundsOf(text).H()
+			ctx.Dot.X = bounds.Max.X/4 - ctx.BoundsOf(text).W()/2
+			fmt.Fprintf(ctx, "%s\n", text)
+		})
+	}
 
 	for !window.Closed() {
-		if !gameWin {
-			window.Clear(winBgcolor)
-			context.Clear()
-			winTextContext.Clear()
-			scoreTextContext.Clear()
+		window.Clear(winBgcolor)
+		context.Clear()
+		winTextContext.Clear()
+		scoreTextContext.Clear()
+		promptTextContext.Clear()
+		hintTextContext.Clear()
+		commentTextContext.Clear()
+
+		rev.handle(window, state)
+		// the click starting a new game places no shape in it
+		reset := false
+		if rev.on {
+			// moves are neither made nor taken back while the game
+			// is reviewed
+		} else if state.Phase() == game.PhaseOver {
+			if window.JustPressed(pixelgl.MouseButtonLeft) {
+				if match.Over() {
+					match.Reset()
+				}
+				state.Reset(match.Starter())
+				comments = comments[:0]
+				rev.stop()
+				reset = true
+			}
+		} else if window.JustPressed(undoKey) && canUndo(players) {
+			if pending != nil {
+				pending.cancel()
+				pending = nil
+			}
+			undo(players, state)
+			if len(comments) > len(state.History()) {
+				comments = comments[:len(state.History())]
+			}
 		}
+		if !rev.on && state.Phase() != game.PhaseOver && pending == nil {
+			side := sideIndex(state, state.Current())
+			pending = requestMove(players, side, state)
+		}
+		if !rev.on && !reset {
+			for _, in := range inputs {
+				in.handle(window, state)
+			}
+		}
+		overlay.handle(window, state)
 
-		if window.JustPressed(pixelgl.MouseButtonLeft) {
-			gameWin = handleMouseClick(window, context, winTextContext, g, shapeDecider, scoreKeeper, bounds, gameWin)
+		if pending != nil {
+			select {
+			case m := <-pending.moves:
+				pending.cancel()
+				if m.err != nil {
+					fmt.Fprintf(os.Stderr, "error: %v\n", m.err)
+					window.SetClosed(true)
+				} else {
+					comment := ""
+					if opts.Commentary {
+						comment = commentary.Comment(state, m.move, oracle)
+					}
+					if err := state.Apply(m.move); err == nil {
+						for len(comments) < len(state.History())-1 {
+							comments = append(comments, "")
+						}
+						comments = append(comments, comment)
+						notifyPlayers(players, pending.side, m.move)
+						handleGameOver(state, match, scoreKeeper, opts.SaveDir, profiles, comments)
+					} else if _, ok := players[pending.side].(*player.Human); !ok {
+						// only humans are asked again for a move
+						// after an illegal one
+						fmt.Fprintf(os.Stderr, "error: %s played an illegal move %v: %v\n", state.Current().Name, m.move, err)
+						window.SetClosed(true)
+					}
+				}
+				pending = nil
+			default:
+			}
 		}
 
 		g.Render(context)
+		for _, in := range inputs {
+			in.render(context, state)
+		}
+		overlay.render(context, hintTextContext, state)
+		if rev.on {
+			rev.render(context, state)
+		}
+		for _, cell := range state.Forbidden() {
+			cell.RenderMark(context, forbiddenColor)
+		}
+		if result := state.Result(); result != nil && !rev.on {
+			drawResult(context, winTextContext, state, match, result)
+		}
 		scoreRenderer.Render(scoreTextContext, scoreKeeper)
+		if rev.on {
+			fmt.Fprint(promptTextContext, rev.promptText())
+			drawComment(commentTextContext, rev.text(state.Grid.Size()))
+		} else {
+			fmt.Fprint(promptTextContext, promptText(state))
+			if n := len(state.History()); n > 0 && len(comments) == n {
+				drawComment(commentTextContext, comments[n-1])
+			}
+		}
 		context.Draw(window)
+		hintTextContext.Draw(window, pixel.IM)
 		winTextContext.Draw(window, pixel.IM.Scaled(winTextContext.Orig, winTextSize))
 		scoreTextContext.Draw(window, pixel.IM.Scaled(scoreTextContext.Orig, scoreTextSize))
+		promptTextContext.Draw(window, pixel.IM.Scaled(promptTextContext.Orig, promptTextSize))
+		commentTextContext.Draw(window, pixel.IM.Scaled(commentTextContext.Orig, commentTextSize))
 		window.Update()
 	}
+
+	if pending != nil {
+		pending.cancel()
+	}
+	overlay.stop()
+	rev.stop()
+	for _, p := range players {
+		// closing an adaptive player saves the habits it learned
+		if closer, ok := p.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "error: unable to close player: %v\n", err)
+			}
+		}
+	}
 }
 
-func handleMouseClick(window *pixelgl.Window, context *imdraw.IMDraw, winTextContext *text.Text, g grid.Grid, shapeDecider *shape.ShapeDecider, scoreKeeper score.ScoreKeeper, bounds pixel.Rect, gameWin bool) bool {
-	if gameWin {
-		window.Clear(winBgcolor)
-		g.Reset()
-		return false
+// canUndo returns true if moves can be taken back, which is not the
+// case when playing against a remote player keeping its own copy of
+// the game.
+func canUndo(players []player.Player) bool {
+	for _, p := range players {
+		if _, ok := p.(player.Observer); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// undo takes back the last move made in state. When playing against
+// the computer, its moves are taken back too until a human is to
+// move again.
+func undo(players []player.Player, state *game.State) {
+	human := false
+	for _, p := range players {
+		if _, ok := p.(*player.Human); ok {
+			human = true
+		}
+	}
+
+	for state.Undo() == nil {
+		if _, ok := players[sideIndex(state, state.Current())].(*player.Human); ok || !human {
+			return
+		}
+	}
+}
+
+// notifyPlayers tells every player other than the one at index
+// side about a move made by that side.
+func notifyPlayers(players []player.Player, side int, m game.Move) {
+	for i, p := range players {
+		if observer, ok := p.(player.Observer); ok && i != side {
+			if err := observer.Observe(m); err != nil {
+				fmt.Fprintf(os.Stderr, "error: unable to send move: %v\n", err)
+			}
+		}
 	}
+}
 
-	if cell := g.AtVector(window.MousePosition()); cell != nil {
-		cell.Set(shape.NewShape(pixel.V(cell.Start().X, cell.Start().Y), shapeDecider.Next(), (bounds.Max.X-cellMargin*2)/grid.MaxCells, (bounds.Max.Y-cellMargin*2)/grid.MaxCells, shapeMargin))
-		return g.CheckWin(context, winTextContext, scoreKeeper)
+func sideIndex(state *game.State, side *game.Side) int {
+	for i := range state.Sides() {
+		if state.Sides()[i] == side {
+			return i
+		}
 	}
+	return 0
+}
 
-	return false
+// handleGameOver records the result of the game in s once the last
+// move has been played. Saved games record the profile each side
+// was played under, as listed in profiles, and the commentary on
+// every move, if any.
+func handleGameOver(state *game.State, match *game.Match, scoreKeeper score.ScoreKeeper, saveDir string, profiles map[string]string, comments []string) {
+	if result := state.Result(); result != nil {
+		match.Record(state)
+		if saveDir != "" {
+			g := record.FromState(state)
+			if len(profiles) > 0 {
+				g.Profiles = profiles
+			}
+			for i := range g.Moves {
+				if i < len(comments) {
+					g.Moves[i].Comment = comments[i]
+				}
+			}
+			if err := record.Save(saveDir, g); err != nil {
+				fmt.Fprintf(os.Stderr, "error: unable to save game: %v\n", err)
+			}
+		}
+		if result.Winner != "" {
+			scoreKeeper.Add(state.SideOf(result.Winner).Name, 1)
+		}
+	}
+}
+
+// standingText returns the current standing of a match, with the
+// wins of the first player listed first.
+func standingText(match *game.Match) string {
+	return fmt.Sprintf("%s  %d - %d", match.Format, match.Wins(0), match.Wins(1))
+}
+
+// scoreText returns the score line shown for the side at index i,
+// including any handicap it plays with and the number of pairs it
+// has captured in variants with captures.
+func scoreText(state *game.State, scores score.ScoreKeeper, i int) string {
+	side := state.Sides()[i]
+	text := fmt.Sprintf("%s (%s): %d", side.Name, side.Kind, scores.Get(side.Name))
+	if handicap := state.Rules.Handicap.Describe(i); handicap != "" {
+		text += fmt.Sprintf(" [%s]", handicap)
+	}
+	if state.Rules.Variant.Captures {
+		text += fmt.Sprintf("  PAIRS: %d", state.Captures(side.Kind))
+	}
+	return text
+}
+
+// promptText returns the instructions shown to the side expected
+// to act next. Outside of openings, instructions are only needed
+// to tell team members whose turn it is.
+func promptText(state *game.State) string {
+	side := state.Current()
+	switch state.Phase() {
+	case game.PhaseOpening:
+		return fmt.Sprintf("%s: PLACE %d MORE OPENING STONE(S)", side.Player(), state.OpeningStones())
+	case game.PhaseChoice:
+		options := []string{}
+		for _, choice := range state.Choices() {
+			label := strings.ToUpper(string(choice))
+			options = append(options, "["+label[:1]+"]"+label[1:])
+		}
+		return fmt.Sprintf("%s: %s", side.Player(), strings.Join(options, ", "))
+	case game.PhasePlay:
+		if state.Rules.Teams {
+			return fmt.Sprintf("%s: %s TO MOVE", side.Name, side.Player())
+		}
+	case game.PhaseOver:
+		return "PRESS R TO REVIEW THE GAME"
+	}
+	return ""
+}
+
+func drawResult(context *imdraw.IMDraw, textContext *text.Text, state *game.State, match *game.Match, result *game.Result) {
+	if result.Run != nil {
+		state.Grid.DrawRun(context, result.Run)
+	}
+	if match.Over() && match.Format != game.SingleGame {
+		drawText(textContext, getMatchWinText(state, match))
+		return
+	}
+	if result.Winner == "" {
+		drawText(textContext, "TIE!")
+		return
+	}
+	drawText(textContext, getWinText(state.SideOf(result.Winner)))
+}
+
+func drawText(context *text.Text, contents string) {
+	context.Dot.X -= context.BoundsOf(contents).W() / 2
+	context.Dot.Y -= context.BoundsOf(contents).H() / 2
+	fmt.Fprintf(context, "%s\n", contents)
+}
+
+// drawComment writes a line of commentary centered on the origin
+// of context.
+func drawComment(context *text.Text, comment string) {
+	context.Dot.X -= context.BoundsOf(comment).W() / 2
+	fmt.Fprint(context, comment)
+}
+
+// getWinText returns the string of text presented on a win
+// depending on the winning side.
+func getWinText(winner *game.Side) string {
+	return fmt.Sprintf("%s WINS!", winner.Name)
+}
+
+// getMatchWinText returns the string of text presented once a
+// match is over.
+func getMatchWinText(state *game.State, match *game.Match) string {
+	winner := match.Winner()
+	if winner < 0 {
+		return "MATCH TIED!"
+	}
+	return fmt.Sprintf("%s WINS THE MATCH!", state.Sides()[winner].Name)
 }
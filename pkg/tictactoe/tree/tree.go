@@ -0,0 +1,272 @@
+package tree
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/commentary"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/grid"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/shape"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/solver"
+)
+
+const (
+	crossWinColor  = "palegreen"
+	circleWinColor = "lightpink"
+	drawColor      = "lightgrey"
+)
+
+// Config selects the part of the game tree exported.
+type Config struct {
+	// Depth bounds the number of moves from the root, or is zero for
+	// the whole tree.
+	Depth int
+	// Symmetry keeps a single move out of those leading to the same
+	// position up to a rotation or reflection of the board.
+	Symmetry bool
+	// Transpositions merges the nodes of positions reached by
+	// different orders of moves, turning the tree into a graph. With
+	// Symmetry, positions equal up to a rotation or reflection are
+	// merged too.
+	Transpositions bool
+}
+
+type node struct {
+	p        solver.Position
+	value    solver.Value
+	distance int
+	children []edge
+	pv       bool
+}
+
+type edge struct {
+	move  int
+	child int
+	pv    bool
+}
+
+// builder grows the tree, keeping its nodes by the order they were
+// first reached in.
+type builder struct {
+	oracle   *solver.Oracle
+	config   Config
+	nodes    []*node
+	seen     map[string]int
+	symmetry [8][]int
+}
+
+// Write writes the game tree from root in the DOT format of
+// Graphviz. Every node shows its position and its value with
+// perfect play, and the principal variation, the moves both sides
+// play with perfect play, is drawn in bold.
+func Write(w io.Writer, root solver.Position, oracle *solver.Oracle, config Config) error {
+	b := &builder{
+		oracle:   oracle,
+		config:   config,
+		seen:     make(map[string]int),
+		symmetry: grid.Symmetries(root.Size),
+	}
+	if _, err := b.grow(root, 0); err != nil {
+		return err
+	}
+	b.markPV(0)
+	return b.write(w)
+}
+
+// grow adds the node of p, reached after depth moves, and the nodes
+// below it, returning its index.
+func (b *builder) grow(p solver.Position, depth int) (int, error) {
+	key := ""
+	if b.config.Transpositions {
+		key = b.key(p)
+		if i, ok := b.seen[key]; ok {
+			return i, nil
+		}
+	}
+
+	value, distance, err := b.oracle.Evaluate(p)
+	if err != nil {
+		return 0, err
+	}
+	i := len(b.nodes)
+	n := &node{p: p, value: value, distance: distance}
+	b.nodes = append(b.nodes, n)
+	if b.config.Transpositions {
+		b.seen[key] = i
+	}
+
+	// the game is over once no moves are left to be played
+	if distance == 0 || (b.config.Depth > 0 && depth >= b.config.Depth) {
+		return i, nil
+	}
+	for _, move := range b.moves(p) {
+		child, err := b.grow(p.Play(move), depth+1)
+		if err != nil {
+			return 0, err
+		}
+		n.children = append(n.children, edge{move: move, child: child})
+	}
+	return i, nil
+}
+
+// moves returns the empty cells of p, leaving out, if reducing by
+// symmetry, the cells a rotation or reflection of the board leaving
+// p unchanged maps an earlier cell onto.
+func (b *builder) moves(p solver.Position) []int {
+	symmetries := [][]int{}
+	if b.config.Symmetry {
+		for _, sym := range b.symmetry {
+			if invariant(p, sym) {
+				symmetries = append(symmetries, sym)
+			}
+		}
+	}
+
+	moves := []int{}
+	for i, kind := range p.Cells {
+		if kind != "" {
+			continue
+		}
+		first := true
+		for _, sym := range symmetries {
+			if sym[i] < i {
+				first = false
+			}
+		}
+		if first {
+			moves = append(moves, i)
+		}
+	}
+	return moves
+}
+
+func invariant(p solver.Position, sym []int) bool {
+	for i, kind := range p.Cells {
+		if p.Cells[sym[i]] != kind {
+			return false
+		}
+	}
+	return true
+}
+
+// key returns the key nodes are merged by: the cells of p, in the
+// orientation writing them first, if reducing by symmetry.
+func (b *builder) key(p solver.Position) string {
+	if !b.config.Symmetry {
+		return cells(p.Cells)
+	}
+	best := ""
+	for _, sym := range b.symmetry {
+		oriented := make([]shape.ShapeKind, len(p.Cells))
+		for i, kind := range p.Cells {
+			oriented[sym[i]] = kind
+		}
+		if k := cells(oriented); best == "" || k < best {
+			best = k
+		}
+	}
+	return best
+}
+
+func cells(kinds []shape.ShapeKind) string {
+	var s strings.Builder
+	for _, kind := range kinds {
+		if kind == "" {
+			s.WriteByte('.')
+			continue
+		}
+		s.WriteString(strings.ToLower(string(kind)))
+	}
+	return s.String()
+}
+
+// markPV marks the principal variation from the node at index i:
+// at every node, the first move keeping its value and the number of
+// moves left until the game ends.
+func (b *builder) markPV(i int) {
+	for n := b.nodes[i]; !n.pv; {
+		n.pv = true
+		next := -1
+		for j, e := range n.children {
+			child := b.nodes[e.child]
+			if -child.value != n.value {
+				continue
+			}
+			if child.distance+1 == n.distance {
+				next = j
+				break
+			}
+			if next < 0 {
+				next = j
+			}
+		}
+		if next < 0 {
+			return
+		}
+		n.children[next].pv = true
+		n = b.nodes[n.children[next].child]
+	}
+}
+
+func (b *builder) write(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "digraph tree {")
+	fmt.Fprintln(out, `	node [shape=box, style=filled, fontname="monospace"];`)
+	for i, n := range b.nodes {
+		attrs := fmt.Sprintf(`label="%s", fillcolor=%s`, label(n), color(n))
+		if n.distance == 0 {
+			attrs += ", shape=doubleoctagon"
+		}
+		if n.pv {
+			attrs += ", penwidth=3"
+		}
+		fmt.Fprintf(out, "\tn%d [%s];\n", i, attrs)
+	}
+	for i, n := range b.nodes {
+		for _, e := range n.children {
+			attrs := fmt.Sprintf(`label="%s"`, commentary.Cell(e.move%n.p.Size, e.move/n.p.Size, n.p.Size))
+			if e.pv {
+				attrs += ", penwidth=3, color=red"
+			}
+			fmt.Fprintf(out, "\tn%d -> n%d [%s];\n", i, e.child, attrs)
+		}
+	}
+	fmt.Fprintln(out, "}")
+	return out.Flush()
+}
+
+// label returns the rows of the position of n, followed by its value
+// for X with perfect play: 1 for a win, 0 for a draw and -1 for a
+// loss, and the number of moves left until the game ends.
+func label(n *node) string {
+	var s strings.Builder
+	for y := 0; y < n.p.Size; y++ {
+		s.WriteString(cells(n.p.Cells[y*n.p.Size:(y+1)*n.p.Size]) + `\n`)
+	}
+	value := fmt.Sprintf("%d", crossValue(n))
+	if crossValue(n) == solver.Win {
+		value = "+1"
+	}
+	fmt.Fprintf(&s, "%s in %d", value, n.distance)
+	return s.String()
+}
+
+func color(n *node) string {
+	switch crossValue(n) {
+	case solver.Win:
+		return crossWinColor
+	case solver.Loss:
+		return circleWinColor
+	}
+	return drawColor
+}
+
+// crossValue returns the value of n for X.
+func crossValue(n *node) solver.Value {
+	if n.p.Turn == shape.CrossShape {
+		return n.value
+	}
+	return -n.value
+}
@@ -0,0 +1,71 @@
+package tree
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/solver"
+)
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		nodes  int
+		// pv is the number of moves of the principal variation.
+		pv int
+	}{
+		{name: "whole tree", config: Config{}, nodes: 549946, pv: 9},
+		{name: "transpositions", config: Config{Transpositions: true}, nodes: 5478, pv: 9},
+		{name: "symmetry and transpositions", config: Config{Symmetry: true, Transpositions: true}, nodes: 765, pv: 9},
+		{name: "first move", config: Config{Depth: 1}, nodes: 10, pv: 1},
+		{name: "first move up to symmetry", config: Config{Depth: 1, Symmetry: true}, nodes: 4, pv: 1},
+	}
+
+	root, err := solver.ParsePosition(".../.../...", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oracle := solver.NewOracle()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if testing.Short() && test.nodes > 10000 {
+				t.Skip("skipping the whole tree in short mode")
+			}
+			var buf bytes.Buffer
+			if err := Write(&buf, root, oracle, test.config); err != nil {
+				t.Fatal(err)
+			}
+
+			nodes, edges, pvNodes, pvEdges := 0, 0, 0, 0
+			for _, line := range strings.Split(buf.String(), "\n") {
+				switch {
+				case strings.Contains(line, "->"):
+					edges++
+					if strings.Contains(line, "color=red") {
+						pvEdges++
+					}
+				case strings.HasPrefix(line, "\tn") && !strings.HasPrefix(line, "\tnode"):
+					nodes++
+					if strings.Contains(line, "penwidth=3") {
+						pvNodes++
+						// perfect play from the empty board draws
+						if !strings.Contains(line, "fillcolor="+drawColor) {
+							t.Errorf("expected the principal variation to draw, got %s", line)
+						}
+					}
+				}
+			}
+			if nodes != test.nodes {
+				t.Errorf("expected %d nodes, got %d", test.nodes, nodes)
+			}
+			if !test.config.Transpositions && edges != nodes-1 {
+				t.Errorf("expected a tree of %d edges, got %d", nodes-1, edges)
+			}
+			if pvNodes != test.pv+1 || pvEdges != test.pv {
+				t.Errorf("expected a principal variation of %d moves, got %d nodes and %d edges", test.pv, pvNodes, pvEdges)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package tune
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/ai"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/arena"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+)
+
+// spread is the standard deviation of the logarithm of the factor
+// mutated weights are multiplied by.
+const spread = 0.5
+
+// Config describes how weights are evolved.
+type Config struct {
+	Variant *game.Variant
+	// Difficulty is the difficulty every individual plays at. Only
+	// positions where the search stops before the end of the game are
+	// evaluated, so it should limit how deep the search goes.
+	Difficulty ai.Difficulty
+
+	// Population is the number of individuals in each generation,
+	// and Generations the number of generations bred.
+	Population  int
+	Generations int
+	// Mutation is the probability of each weight of a child being
+	// mutated.
+	Mutation float64
+	// Games is the number of games played by every pair of
+	// individuals of a generation.
+	Games int
+	// Workers is the number of games played at once, or zero to
+	// play one per CPU.
+	Workers int
+	// Seed seeds the breeding and the games played, so the same
+	// weights are evolved again from the same seed.
+	Seed int64
+
+	// Start are the weights the first generation is bred from.
+	Start ai.Weights
+
+	// Generation, if set, is called after the games of every
+	// generation are played, with its number counting from one, its
+	// individuals, and how each of them fared.
+	Generation func(n int, population []ai.Weights, standings []arena.Standing)
+}
+
+// Evolve breeds weights with a genetic algorithm. The individuals of
+// every generation play each other, and the best quarter of them are
+// kept for the next generation, which is filled with the children of
+// parents picked among the better individuals. It returns the weights
+// of the best individual of the last generation.
+func Evolve(ctx context.Context, config Config) (ai.Weights, error) {
+	if config.Population < 2 || config.Generations < 1 || config.Games < 1 {
+		return ai.Weights{}, errors.New("at least two individuals must play a game over a generation")
+	}
+	r := rand.New(rand.NewSource(config.Seed))
+
+	population := []ai.Weights{config.Start}
+	for len(population) < config.Population {
+		population = append(population, mutate(config.Start, 1, r))
+	}
+
+	for n := 1; ; n++ {
+		standings, err := play(ctx, config, n, population)
+		if err != nil {
+			return ai.Weights{}, err
+		}
+		if config.Generation != nil {
+			config.Generation(n, population, standings)
+		}
+		if n == config.Generations {
+			return population[standings[0].Entrant], nil
+		}
+
+		ranked := []ai.Weights{}
+		for _, s := range standings {
+			ranked = append(ranked, population[s.Entrant])
+		}
+		elite := config.Population / 4
+		if elite < 1 {
+			elite = 1
+		}
+		population = append([]ai.Weights{}, ranked[:elite]...)
+		for len(population) < config.Population {
+			child := crossover(pick(ranked, r), pick(ranked, r), r)
+			population = append(population, mutate(child, config.Mutation, r))
+		}
+	}
+}
+
+// play plays the games of generation n between the individuals of
+// population, and returns how each fared, best first.
+func play(ctx context.Context, config Config, n int, population []ai.Weights) ([]arena.Standing, error) {
+	entrants := []arena.Entrant{}
+	for _, w := range population {
+		w := w
+		entrants = append(entrants, arena.Entrant{
+			New: func(seed int64) (ai.Bot, error) {
+				m := ai.NewMinimax(config.Difficulty, seed)
+				m.Weights = &w
+				return m, nil
+			},
+		})
+	}
+
+	games, err := arena.Run(ctx, arena.Config{
+		Variant: config.Variant,
+		Games:   config.Games,
+		Workers: config.Workers,
+		Seed:    config.Seed + int64(n)<<32,
+	}, entrants)
+	if err != nil {
+		return nil, err
+	}
+	return arena.Standings(games, len(population)), nil
+}
+
+// pick picks the better of two individuals of ranked chosen at
+// random.
+func pick(ranked []ai.Weights, r *rand.Rand) ai.Weights {
+	i, j := r.Intn(len(ranked)), r.Intn(len(ranked))
+	if j < i {
+		i = j
+	}
+	return ranked[i]
+}
+
+// crossover returns a child taking each weight from either parent.
+func crossover(a, b ai.Weights, r *rand.Rand) ai.Weights {
+	child := a
+	for p := range child {
+		if r.Intn(2) == 0 {
+			child[p] = b[p]
+		}
+	}
+	return child
+}
+
+// mutate returns w with each weight multiplied, with probability p,
+// by a random factor. Weights stay between 1 and ai.MaxWeight, so a
+// weight of zero can grow again.
+func mutate(w ai.Weights, p float64, r *rand.Rand) ai.Weights {
+	for i, weight := range w {
+		if r.Float64() >= p {
+			continue
+		}
+		v := math.Max(float64(weight), 1) * math.Exp(r.NormFloat64()*spread)
+		w[i] = int(math.Round(math.Min(math.Max(v, 1), ai.MaxWeight)))
+	}
+	return w
+}
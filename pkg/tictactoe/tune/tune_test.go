@@ -0,0 +1,63 @@
+package tune
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/ai"
+	"github.com/juanvallejo/go-tictactoe/pkg/tictactoe/game"
+)
+
+func TestEvolveSeeded(t *testing.T) {
+	config := Config{
+		Variant:     game.Standard,
+		Difficulty:  ai.Difficulty{Name: "test", Depth: 1},
+		Population:  3,
+		Generations: 2,
+		Mutation:    0.5,
+		Games:       1,
+		Workers:     2,
+		Seed:        1,
+		Start:       ai.DefaultWeights,
+	}
+	first, err := Evolve(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Evolve(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Errorf("expected the same weights from the same seed, got %v and %v", first, second)
+	}
+}
+
+func TestMutate(t *testing.T) {
+	var low, high ai.Weights
+	for i := range high {
+		high[i] = ai.MaxWeight
+	}
+	tests := []struct {
+		name string
+		w    ai.Weights
+	}{
+		{name: "default", w: ai.DefaultWeights},
+		{name: "zero", w: low},
+		{name: "largest", w: high},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(1))
+			for i := 0; i < 1000; i++ {
+				for j, weight := range mutate(test.w, 1, r) {
+					if weight < 1 || weight > ai.MaxWeight {
+						t.Fatalf("expected weight %d between 1 and %d, got %d", j, ai.MaxWeight, weight)
+					}
+				}
+			}
+		})
+	}
+}